@@ -18,15 +18,22 @@ package connector
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/rs/zerolog"
 	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
 	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
 
 	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
 )
 
+// forwardBackfillPageSize is the number of messages requested per page when
+// paginating forwards from the anchor message.
+const forwardBackfillPageSize = 20
+
 var (
 	_ bridgev2.BackfillingNetworkAPI = (*LinkedInClient)(nil)
 )
@@ -38,6 +45,8 @@ func (l *LinkedInClient) FetchMessages(ctx context.Context, fetchParams bridgev2
 	portal, err := l.main.Bridge.GetPortalByKey(ctx, fetchParams.Portal.PortalKey)
 	if err != nil {
 		return nil, err
+	} else if isNotificationsPortal(portal) {
+		return l.fetchNotifications(ctx, portal, fetchParams)
 	}
 
 	resp := bridgev2.FetchMessagesResponse{
@@ -45,9 +54,16 @@ func (l *LinkedInClient) FetchMessages(ctx context.Context, fetchParams bridgev2
 		MarkRead: true,
 	}
 
-	convURN := linkedingo.NewURN(fetchParams.Portal.ID)
+	convURN := l.conversationURN(fetchParams.Portal.ID)
 	var messages []linkedingo.Message
-	if fetchParams.Cursor != "" {
+	if fetchParams.Forward && fetchParams.AnchorMessage != nil {
+		messages, err = l.fetchMessagesAfter(ctx, convURN, fetchParams.AnchorMessage, fetchParams.Count)
+		if err != nil {
+			return nil, err
+		} else if len(messages) == 0 {
+			return &bridgev2.FetchMessagesResponse{HasMore: false, Forward: fetchParams.Forward}, nil
+		}
+	} else if fetchParams.Cursor != "" {
 		msgs, err := l.client.GetMessagesWithPrevCursor(ctx, convURN, string(fetchParams.Cursor), fetchParams.Count)
 		if err != nil {
 			return nil, err
@@ -57,7 +73,7 @@ func (l *LinkedInClient) FetchMessages(ctx context.Context, fetchParams bridgev2
 		messages = msgs.Elements
 		resp.Cursor = networkid.PaginationCursor(msgs.Metadata.PrevCursor)
 	} else {
-		msgs, err := l.client.GetMessagesBefore(ctx, linkedingo.NewURN(fetchParams.Portal.ID), time.Now(), fetchParams.Count)
+		msgs, err := l.client.GetMessagesBefore(ctx, l.conversationURN(fetchParams.Portal.ID), time.Now(), fetchParams.Count)
 		if err != nil {
 			return nil, err
 		} else if len(msgs.Elements) == 0 {
@@ -73,58 +89,80 @@ func (l *LinkedInClient) FetchMessages(ctx context.Context, fetchParams bridgev2
 		log = log.With().Time("stop_at", stopAt).Logger()
 	}
 
-	lastRead := l.conversationLastRead[convURN]
+	lastRead := getPortalMetadata(portal).LastReadAt[l.userLogin.ID]
 
+	// Recalled messages are filtered out, so whether there are more messages
+	// is based on the number of messages fetched from LinkedIn.
+	fetchedCount := len(messages)
+	filtered := messages[:0]
 	for _, msg := range messages {
-		log := log.With().Stringer("entity_urn", msg.EntityURN).Logger()
-		ctx := log.WithContext(ctx)
+		if msg.MessageBodyRenderFormat == linkedingo.MessageBodyRenderFormatRecalled {
+			// Recalled messages don't have any content left, but they may
+			// have been bridged before they were recalled.
+			log.Debug().Stringer("entity_urn", msg.EntityURN).Msg("skipping recalled message")
+			l.redactRecalledMessage(ctx, portal, msg)
+			continue
+		}
 		if !stopAt.IsZero() {
 			if fetchParams.Forward {
-				if !msg.DeliveredAt.Time.After(stopAt) {
-					// If we are doing forward backfill skip any messages that are before the anchor message
-					log.Debug().Msg("skipping message before anchor message")
+				if msg.DeliveredAt.Time.Before(stopAt) || l.messageID(portal.PortalKey, msg.EntityURN) == fetchParams.AnchorMessage.ID {
+					// If we are doing forward backfill skip the anchor
+					// message and any messages that are before it. Messages
+					// with the same timestamp are kept, bridgev2 deduplicates
+					// them.
+					log.Debug().Stringer("entity_urn", msg.EntityURN).Msg("skipping message before anchor message")
 					continue
 				}
 			} else if !msg.DeliveredAt.Time.Before(stopAt) {
 				// If we are doing backwards backfill and we got to a message
 				// more recent or equal to the anchor message, skip it.
-				log.Debug().Msg("skipping message past anchor message")
+				log.Debug().Stringer("entity_urn", msg.EntityURN).Msg("skipping message past anchor message")
 				continue
 			}
 		}
+		filtered = append(filtered, msg)
+	}
+	messages = filtered
+
+	reactions := l.fetchBackfillReactions(ctx, portal, messages)
+
+	var edited []linkedingo.Message
+
+	for _, msg := range messages {
+		log := log.With().Stringer("entity_urn", msg.EntityURN).Logger()
+		ctx := log.WithContext(ctx)
 
 		sender := l.makeSender(msg.Sender)
+		convert := l.convertToMatrix
+		if msg.MessageBodyRenderFormat == linkedingo.MessageBodyRenderFormatSystem {
+			// System messages are sent as notices by the bridge bot.
+			sender = bridgev2.EventSender{}
+			convert = l.convertSystemMessageToMatrix
+		}
 
 		intent, ok := portal.GetIntentFor(ctx, sender, l.userLogin, bridgev2.RemoteEventBackfill)
 		if !ok {
 			continue
 		}
-		converted, err := l.convertToMatrix(ctx, portal, intent, msg)
+		converted, err := convert(ctx, portal, intent, msg)
 		if err != nil {
 			return nil, err
 		}
+		switch msg.MessageBodyRenderFormat {
+		case linkedingo.MessageBodyRenderFormatDefault, linkedingo.MessageBodyRenderFormatSystem:
+		case linkedingo.MessageBodyRenderFormatEdited:
+			edited = append(edited, msg)
+		default:
+			log.Warn().Str("message_body_render_format", string(msg.MessageBodyRenderFormat)).Msg("Unknown render format")
+		}
 
 		backfillMessage := bridgev2.BackfillMessage{
 			ConvertedMessage: converted,
 			Sender:           sender,
-			ID:               msg.MessageID(),
+			ID:               l.messageID(portal.PortalKey, msg.EntityURN),
 			Timestamp:        msg.DeliveredAt.Time,
 			StreamOrder:      msg.DeliveredAt.UnixMilli(),
-		}
-
-		for _, rs := range msg.ReactionSummaries {
-			reactors, err := l.client.GetReactors(ctx, msg.EntityURN, rs.Emoji)
-			if err != nil {
-				log.Err(err).Msg("failed to get reactors")
-				continue
-			}
-			for _, reactor := range reactors.Elements {
-				backfillMessage.Reactions = append(backfillMessage.Reactions, &bridgev2.BackfillReaction{
-					Sender:  l.makeSender(reactor),
-					EmojiID: networkid.EmojiID(rs.Emoji),
-					Emoji:   rs.Emoji,
-				})
-			}
+			Reactions:        reactions[msg.EntityURN],
 		}
 
 		resp.Messages = append(resp.Messages, &backfillMessage)
@@ -134,6 +172,121 @@ func (l *LinkedInClient) FetchMessages(ctx context.Context, fetchParams bridgev2
 		}
 	}
 
-	resp.HasMore = len(resp.Messages) > 0
+	sendReceipts := fetchParams.Forward && len(resp.Messages) > 0
+	if sendReceipts || len(edited) > 0 {
+		resp.CompleteCallback = func() {
+			if sendReceipts {
+				// Forward backfills contain the newest messages, so they're
+				// where the read receipts of the user and other participants
+				// belong.
+				l.queueBackfillReceipts(portal, resp.Messages)
+			}
+			l.queueBackfilledEdits(portal, edited)
+		}
+	}
+
+	if fetchParams.Forward && fetchParams.AnchorMessage != nil {
+		resp.HasMore = fetchedCount >= fetchParams.Count
+		resp.AggressiveDeduplication = true
+	} else {
+		resp.HasMore = fetchedCount > 0
+	}
 	return &resp, nil
 }
+
+// fetchMessagesAfter pages forwards from the anchor message until count new
+// messages have been fetched or there are no newer messages. The anchor
+// message itself isn't included. The messages are returned oldest first.
+func (l *LinkedInClient) fetchMessagesAfter(ctx context.Context, convURN linkedingo.URN, anchor *database.Message, count int) ([]linkedingo.Message, error) {
+	var messages []linkedingo.Message
+	seen := map[linkedingo.URN]struct{}{}
+	after := anchor.Timestamp
+	// Messages delivered at the same time as after are returned again by the
+	// next request, so they're requested in addition to the new messages.
+	repeated := 1
+	for len(messages) < count {
+		pageSize := min(forwardBackfillPageSize, count-len(messages)) + repeated
+		page, err := l.client.GetMessagesAfter(ctx, convURN, after, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		slices.SortStableFunc(page.Elements, func(a, b linkedingo.Message) int {
+			return a.DeliveredAt.Compare(b.DeliveredAt.Time)
+		})
+		var added int
+		for _, msg := range page.Elements {
+			if _, ok := seen[msg.EntityURN]; ok || msg.DeliveredAt.Before(after) || l.messageID(anchor.Room, msg.EntityURN) == anchor.ID {
+				continue
+			}
+			seen[msg.EntityURN] = struct{}{}
+			messages = append(messages, msg)
+			added++
+		}
+		if added == 0 || len(page.Elements) < pageSize {
+			break
+		}
+		after = messages[len(messages)-1].DeliveredAt.Time
+		repeated = 0
+		for _, msg := range page.Elements {
+			if msg.DeliveredAt.Equal(after) {
+				repeated++
+			}
+		}
+	}
+	if len(messages) > count {
+		messages = messages[:count]
+	}
+	return messages, nil
+}
+
+// redactRecalledMessage removes a message which was recalled on LinkedIn if it
+// was bridged before it was recalled.
+func (l *LinkedInClient) redactRecalledMessage(ctx context.Context, portal *bridgev2.Portal, msg linkedingo.Message) {
+	existing, err := l.main.Bridge.DB.Message.GetFirstPartByID(ctx, portal.Receiver, l.messageID(portal.PortalKey, msg.EntityURN))
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Stringer("entity_urn", msg.EntityURN).Msg("Failed to check if recalled message was bridged")
+		return
+	} else if existing == nil {
+		return
+	}
+	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.MessageRemove{
+		EventMeta: simplevent.EventMeta{
+			Type: bridgev2.RemoteEventMessageRemove,
+			LogContext: func(c zerolog.Context) zerolog.Context {
+				return c.
+					Str("update", "backfill_recall").
+					Stringer("entity_urn", msg.EntityURN)
+			},
+			PortalKey: portal.PortalKey,
+			Sender:    l.makeSender(msg.Sender),
+			Timestamp: msg.DeliveredAt.Time,
+		},
+		TargetMessage: l.messageID(portal.PortalKey, msg.EntityURN),
+	})
+}
+
+// queueBackfilledEdits marks backfilled messages which were edited on
+// LinkedIn as edited. Backfill only has the latest version of a message, so
+// the edit replaces the message with the same content once it has been
+// bridged.
+func (l *LinkedInClient) queueBackfilledEdits(portal *bridgev2.Portal, messages []linkedingo.Message) {
+	for _, msg := range messages {
+		l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Message[linkedingo.Message]{
+			EventMeta: simplevent.EventMeta{
+				Type: bridgev2.RemoteEventEdit,
+				LogContext: func(c zerolog.Context) zerolog.Context {
+					return c.
+						Str("update", "backfill_edit").
+						Stringer("entity_urn", msg.EntityURN)
+				},
+				PortalKey:   portal.PortalKey,
+				Sender:      l.makeSender(msg.Sender),
+				Timestamp:   msg.DeliveredAt.Time,
+				StreamOrder: msg.DeliveredAt.UnixMilli(),
+			},
+			TargetMessage:   l.messageID(portal.PortalKey, msg.EntityURN),
+			Data:            msg,
+			ConvertEditFunc: l.convertBackfilledEdit,
+		})
+	}
+}
@@ -50,9 +50,15 @@ func (*LinkedInConnector) GetBridgeInfoVersion() (info, capabilities int) {
 	return 1, 9
 }
 
+// MaxTextLength is the maximum length of a LinkedIn message. Longer messages
+// from Matrix are split into multiple messages, so it's not advertised in the
+// room features.
 const MaxTextLength = 8000
 const MaxFileSize = 20 * 1024 * 1024
 
+// MaxEditAge is how long after sending a message LinkedIn allows editing it.
+const MaxEditAge = 60 * time.Minute
+
 func supportedIfFFmpeg() event.CapabilitySupportLevel {
 	if ffmpeg.Supported() {
 		return event.CapLevelPartialSupport
@@ -60,10 +66,13 @@ func supportedIfFFmpeg() event.CapabilitySupportLevel {
 	return event.CapLevelRejected
 }
 
-func capID() string {
-	base := "fi.mau.linkedin.capabilities.2026_01_18"
+func capID(unicodeFormatting bool) string {
+	base := "fi.mau.linkedin.capabilities.2026_10_19"
 	if ffmpeg.Supported() {
-		return base + "+ffmpeg"
+		base += "+ffmpeg"
+	}
+	if unicodeFormatting {
+		base += "+unicodefmt"
 	}
 	return base
 }
@@ -89,6 +98,30 @@ var formattingCaps = event.FormattingFeatureMap{
 	event.FmtHeaders:            event.CapLevelDropped,
 }
 
+// unicodeFormattingCaps are the formatting capabilities when the
+// unicode_formatting option is enabled. Formatting is still not supported by
+// LinkedIn, but the parser emulates it with plain text.
+var unicodeFormattingCaps = event.FormattingFeatureMap{
+	event.FmtBold:               event.CapLevelPartialSupport,
+	event.FmtItalic:             event.CapLevelPartialSupport,
+	event.FmtUnderline:          event.CapLevelDropped,
+	event.FmtStrikethrough:      event.CapLevelDropped,
+	event.FmtInlineCode:         event.CapLevelPartialSupport,
+	event.FmtCodeBlock:          event.CapLevelPartialSupport,
+	event.FmtSyntaxHighlighting: event.CapLevelDropped,
+	event.FmtBlockquote:         event.CapLevelPartialSupport,
+	event.FmtInlineLink:         event.CapLevelPartialSupport,
+	event.FmtUserLink:           event.CapLevelFullySupported,
+	event.FmtUnorderedList:      event.CapLevelPartialSupport,
+	event.FmtOrderedList:        event.CapLevelPartialSupport,
+	event.FmtListStart:          event.CapLevelPartialSupport,
+	event.FmtListJumpValue:      event.CapLevelDropped,
+	event.FmtCustomEmoji:        event.CapLevelDropped,
+	event.FmtSpoiler:            event.CapLevelDropped,
+	event.FmtSpoilerReason:      event.CapLevelDropped,
+	event.FmtHeaders:            event.CapLevelDropped,
+}
+
 var fileCaps = event.FileFeatureMap{
 	event.MsgImage: {
 		MimeTypes: map[string]event.CapabilitySupportLevel{
@@ -97,44 +130,39 @@ var fileCaps = event.FileFeatureMap{
 			"image/png":  event.CapLevelFullySupported,
 			"image/webp": event.CapLevelFullySupported,
 		},
-		Caption:          event.CapLevelFullySupported,
-		MaxCaptionLength: MaxTextLength,
-		MaxSize:          MaxFileSize,
+		Caption: event.CapLevelFullySupported,
+		MaxSize: MaxFileSize,
 	},
 	event.MsgVideo: {
 		MimeTypes: map[string]event.CapabilitySupportLevel{
 			"video/mp4":       event.CapLevelFullySupported,
 			"video/quicktime": event.CapLevelFullySupported,
 		},
-		Caption:          event.CapLevelFullySupported,
-		MaxCaptionLength: MaxTextLength,
-		MaxSize:          MaxFileSize,
+		Caption: event.CapLevelFullySupported,
+		MaxSize: MaxFileSize,
 	},
 	event.MsgFile: {
 		MimeTypes: map[string]event.CapabilitySupportLevel{
 			"*/*": event.CapLevelFullySupported,
 		},
-		Caption:          event.CapLevelFullySupported,
-		MaxCaptionLength: MaxTextLength,
-		MaxSize:          MaxFileSize,
+		Caption: event.CapLevelFullySupported,
+		MaxSize: MaxFileSize,
 	},
 	event.CapMsgGIF: {
 		MimeTypes: map[string]event.CapabilitySupportLevel{
 			"image/gif": event.CapLevelFullySupported,
 		},
-		Caption:          event.CapLevelFullySupported,
-		MaxCaptionLength: MaxTextLength,
-		MaxSize:          MaxFileSize,
+		Caption: event.CapLevelFullySupported,
+		MaxSize: MaxFileSize,
 	},
 	event.CapMsgVoice: {
 		MimeTypes: map[string]event.CapabilitySupportLevel{
 			"audio/ogg": supportedIfFFmpeg(),
 			"audio/mp4": event.CapLevelFullySupported,
 		},
-		Caption:          event.CapLevelFullySupported,
-		MaxCaptionLength: MaxTextLength,
-		MaxSize:          MaxFileSize,
-		MaxDuration:      ptr.Ptr(jsontime.S(1 * time.Minute)),
+		Caption:     event.CapLevelFullySupported,
+		MaxSize:     MaxFileSize,
+		MaxDuration: ptr.Ptr(jsontime.S(1 * time.Minute)),
 	},
 }
 
@@ -142,16 +170,26 @@ var stateCaps = event.StateFeatureMap{
 	event.StateRoomName.Type: {Level: event.CapLevelFullySupported},
 }
 
-func (*LinkedInClient) GetCapabilities(ctx context.Context, portal *bridgev2.Portal) *event.RoomFeatures {
+func (l *LinkedInClient) GetCapabilities(ctx context.Context, portal *bridgev2.Portal) *event.RoomFeatures {
+	unicodeFormatting := l.main.Config.UnicodeFormatting
+	if isNotificationsPortal(portal) {
+		return &event.RoomFeatures{
+			ID:         capID(false) + "+notifications",
+			DeleteChat: true,
+		}
+	}
+	fmtCaps := formattingCaps
+	if unicodeFormatting {
+		fmtCaps = unicodeFormattingCaps
+	}
 	return &event.RoomFeatures{
-		ID:                  capID(),
-		Formatting:          formattingCaps,
+		ID:                  capID(unicodeFormatting),
+		Formatting:          fmtCaps,
 		File:                fileCaps,
-		MaxTextLength:       MaxTextLength,
 		LocationMessage:     event.CapLevelDropped,
 		Reply:               event.CapLevelFullySupported,
-		Edit:                event.CapLevelFullySupported, // TODO note that edits are restricted to specific msgtypes
-		EditMaxAge:          ptr.Ptr(jsontime.S(60 * time.Minute)),
+		Edit:                event.CapLevelPartialSupport, // Only text and captions can be edited, attachments can't be replaced
+		EditMaxAge:          ptr.Ptr(jsontime.S(MaxEditAge)),
 		Delete:              event.CapLevelFullySupported,
 		DeleteForMe:         false,
 		DeleteMaxAge:        ptr.Ptr(jsontime.S(60 * time.Minute)),
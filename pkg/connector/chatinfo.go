@@ -75,6 +75,7 @@ func (l *LinkedInClient) conversationToChatInfo(conv linkedingo.Conversation) (c
 	}
 
 	ci.CanBackfill = true
+	ci.ExtraUpdates = l.updateLastReadAt(conv.LastReadAt)
 
 	ci.Members = &bridgev2.ChatMemberList{
 		IsFull:           true,
@@ -86,11 +87,17 @@ func (l *LinkedInClient) conversationToChatInfo(conv linkedingo.Conversation) (c
 			},
 		},
 	}
+	sharedPortal := conv.GroupChat && l.main.sharedGroupPortals()
+	if sharedPortal {
+		// Shared portals are synced by every login in the chat, so nobody is
+		// made a moderator and everyone can remove people.
+		ci.Members.PowerLevels.Kick = ptr.Ptr(0)
+	}
 	for _, participant := range conv.ConversationParticipants {
 		userInChat = userInChat || networkid.UserID(participant.EntityURN.ID()) == l.userID
 		sender := l.makeSender(participant)
 		powerLevel := 0
-		if sender.IsFromMe {
+		if sender.IsFromMe && !sharedPortal {
 			powerLevel = moderatorPL
 		}
 		ci.Members.MemberMap[sender.Sender] = bridgev2.ChatMember{
@@ -25,6 +25,7 @@ import (
 	"maunium.net/go/mautrix/bridgev2"
 	"maunium.net/go/mautrix/bridgev2/networkid"
 	"maunium.net/go/mautrix/bridgev2/simplevent"
+	"maunium.net/go/mautrix/event"
 
 	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
 )
@@ -43,6 +44,26 @@ func (l *LinkedInClient) deletePortal(ctx context.Context, portalKey networkid.P
 	})
 }
 
+// leaveConversation removes the user from the portal after they left the
+// conversation on LinkedIn. Unlike deleting, this keeps the room for the
+// other participants and the history.
+func (l *LinkedInClient) leaveConversation(ctx context.Context, conv linkedingo.Conversation) {
+	self := l.makeSender(linkedingo.MessagingParticipant{EntityURN: linkedingo.NewURN(l.userID)})
+	changes := &bridgev2.ChatMemberList{MemberMap: map[networkid.UserID]bridgev2.ChatMember{}}
+	changes.MemberMap.Set(bridgev2.ChatMember{EventSender: self, Membership: event.MembershipLeave})
+	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.ChatInfoChange{
+		EventMeta: simplevent.EventMeta{
+			Type: bridgev2.RemoteEventChatInfoChange,
+			LogContext: func(c zerolog.Context) zerolog.Context {
+				return c.Str("update", "left_conversation").Stringer("conversation_urn", conv.EntityURN)
+			},
+			PortalKey: l.makePortalKey(conv),
+			Sender:    self,
+		},
+		ChatInfoChange: &bridgev2.ChatInfoChange{MemberChanges: changes},
+	})
+}
+
 func (l *LinkedInClient) deleteURN(ctx context.Context, urn linkedingo.URN) {
 	portalKey := networkid.PortalKey{
 		ID:       networkid.PortalID(urn.String()),
@@ -53,6 +74,11 @@ func (l *LinkedInClient) deleteURN(ctx context.Context, urn linkedingo.URN) {
 		portalKey.Receiver = ""
 		l.deletePortal(ctx, portalKey)
 	}
+	if l.main.sharedGroupPortals() {
+		portalKey.ID = networkid.PortalID(urn.WithID(mailboxlessID(urn)).String())
+		portalKey.Receiver = ""
+		l.deletePortal(ctx, portalKey)
+	}
 }
 
 func (l *LinkedInClient) handleConversations(ctx context.Context, convs []linkedingo.Conversation) {
@@ -78,7 +104,7 @@ func (l *LinkedInClient) handleConversations(ctx context.Context, convs []linked
 			}
 		}
 		if !isMember {
-			l.deleteConversation(ctx, conv)
+			l.leaveConversation(ctx, conv)
 			continue
 		}
 
@@ -87,7 +113,6 @@ func (l *LinkedInClient) handleConversations(ctx context.Context, convs []linked
 			Time("last_activity_at", conv.LastActivityAt.Time).
 			Logger()
 
-		l.conversationLastRead[conv.EntityURN] = conv.LastReadAt
 		readStatusChanged := false
 		lastReadState, ok := l.conversationReadState[conv.EntityURN]
 		if (ok && lastReadState.Read != conv.Read) || !ok {
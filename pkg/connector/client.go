@@ -19,6 +19,7 @@ package connector
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -45,9 +46,25 @@ type LinkedInClient struct {
 	client    *linkedingo.Client
 
 	sessID                uuid.UUID
-	conversationLastRead  map[linkedingo.URN]jsontime.UnixMilli
 	conversationReadState map[linkedingo.URN]ConversationReadState
 
+	seenRealtimeEvents *realtimeEventDedup
+	messageBuffer      *realtimeReorderBuffer
+	typing             *typingTracker
+	outbound           *outboundQueue
+
+	mediaBatches     map[networkid.PortalKey]*mediaBatch
+	mediaBatchesLock sync.Mutex
+
+	pendingSeenReceipts     map[networkid.PortalKey]*pendingSeenReceipts
+	pendingSeenReceiptsLock sync.Mutex
+
+	// metadataLock serializes changes to the login metadata and saving it.
+	metadataLock sync.Mutex
+
+	badgeCounts     map[linkedingo.BadgingItem]int
+	badgeCountsLock sync.RWMutex
+
 	linkedinFmtParams linkedinfmt.FormatParams
 	matrixParser      *matrixfmt.HTMLParser
 }
@@ -66,9 +83,14 @@ func NewLinkedInClient(ctx context.Context, lc *LinkedInConnector, login *bridge
 		main:                  lc,
 		userID:                userID,
 		userLogin:             login,
-		conversationLastRead:  map[linkedingo.URN]jsontime.UnixMilli{},
 		conversationReadState: map[linkedingo.URN]ConversationReadState{},
+		seenRealtimeEvents:    newRealtimeEventDedup(realtimeDedupSize),
+		typing:                newTypingTracker(),
+		outbound:              newOutboundQueue(),
+		mediaBatches:          map[networkid.PortalKey]*mediaBatch{},
+		pendingSeenReceipts:   map[networkid.PortalKey]*pendingSeenReceipts{},
 	}
+	client.messageBuffer = newRealtimeReorderBuffer(realtimeReorderWindow, client.handleRealtimeMessage)
 	meta := login.Metadata.(*UserLoginMetadata)
 	cookies := meta.Cookies
 	if cookies == nil {
@@ -85,11 +107,11 @@ func NewLinkedInClient(ctx context.Context, lc *LinkedInConnector, login *bridge
 		linkedingo.Handlers{
 			Heartbeat: func(ctx context.Context) {
 				if login.BridgeState.GetPrevUnsent().StateEvent != status.StateConnected {
-					login.BridgeState.Send(status.BridgeState{StateEvent: status.StateConnected})
+					login.BridgeState.Send(client.connectedState())
 				}
 			},
 			ClientConnection: func(ctx context.Context, conn *linkedingo.ClientConnection) {
-				login.BridgeState.Send(status.BridgeState{StateEvent: status.StateConnected})
+				login.BridgeState.Send(client.connectedState())
 
 				if client.sessID != conn.SessID {
 					zerolog.Ctx(ctx).Debug().
@@ -105,32 +127,34 @@ func NewLinkedInClient(ctx context.Context, lc *LinkedInConnector, login *bridge
 			UnknownError:        client.onUnknownError,
 			DecoratedEvent:      client.onDecoratedEvent,
 			ConversationsSyncToken: func(ctx context.Context, syncToken string) {
-				meta.ConversationsSyncToken = syncToken
-				err := login.Save(ctx)
+				err := client.updateLoginMetadata(ctx, func(meta *UserLoginMetadata) {
+					meta.ConversationsSyncToken = syncToken
+				})
 				if err != nil {
 					zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to save sync token")
 				}
 			},
+			RealtimeConversation:       client.onRealtimeConversations,
+			RealtimeConversationDelete: client.onRealtimeConversationDelete,
+			RealtimeMessage:            client.onRealtimeMessage,
+			RealtimeTypingIndicator:    client.onRealtimeTypingIndicator,
+			RealtimeSeenReceipt:        client.onRealtimeMessageSeenReceipts,
+			RealtimeReactionSummary:    client.onRealtimeReactionSummaries,
+			RealtimeBadgeCounts:        client.onRealtimeBadgeCounts,
+			RealtimeInAppAlert:         client.onRealtimeInAppAlert,
+			RealtimeInvitation:         client.onRealtimeInvitation,
+			RealtimeReplySuggestions:   client.onRealtimeReplySuggestions,
 		},
 	)
 
 	client.linkedinFmtParams = linkedinfmt.FormatParams{
-		GetMXIDByURN: func(ctx context.Context, entityURN linkedingo.URN) (id.UserID, error) {
-			ghost, err := lc.Bridge.GetGhostByID(ctx, networkid.UserID(entityURN.ID()))
-			if err != nil {
-				return "", err
-			}
-			// FIXME this should look for user logins by ID, not hardcode the current user
-			if networkid.UserID(entityURN.ID()) == client.userID {
-				return client.userLogin.UserMXID, nil
-			}
-			return ghost.Intent.GetMXID(), nil
-		},
+		GetMXIDByURN: lc.getMXIDByURN,
 	}
 	client.matrixParser = &matrixfmt.HTMLParser{
+		UnicodeStyling: lc.Config.UnicodeFormatting,
 		GetGhostDetails: func(ctx context.Context, ui id.UserID) (networkid.UserID, string, bool) {
-			if ui == client.userLogin.UserMXID {
-				return client.userID, client.userLogin.RemoteName, true
+			if login := client.getLoginByMXID(ctx, ui); login != nil {
+				return networkid.UserID(ParseUserLoginID(login.ID)), login.RemoteName, true
 			}
 			if userID, ok := lc.Bridge.Matrix.ParseGhostMXID(ui); !ok {
 				return "", "", false
@@ -158,6 +182,8 @@ func (l *LinkedInClient) Connect(ctx context.Context) {
 	l.userLogin.BridgeState.Send(status.BridgeState{StateEvent: status.StateConnecting})
 
 	l.getConversationsBySyncToken(ctx)
+	l.syncNotificationsPortal()
+	l.resumeOutboundQueue(ctx)
 	if err := l.client.RealtimeConnect(ctx); err != nil {
 		l.userLogin.BridgeState.Send(status.BridgeState{
 			StateEvent: status.StateBadCredentials,
@@ -169,6 +195,11 @@ func (l *LinkedInClient) Connect(ctx context.Context) {
 
 func (l *LinkedInClient) Disconnect() {
 	l.client.RealtimeDisconnect()
+	l.messageBuffer.FlushAll()
+	l.flushAllSeenReceipts()
+	l.typing.StopAll()
+	l.outbound.Stop()
+	l.stopMediaBatches()
 }
 
 func (l *LinkedInClient) IsLoggedIn() bool {
@@ -0,0 +1,243 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"maunium.net/go/mautrix/bridgev2/commands"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/linkedinexport"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+var HelpSectionConnections = commands.HelpSection{Name: "Connections", Order: 25}
+
+var cmdInvitations = &commands.FullHandler{
+	Func: fnInvitations,
+	Name: "invitations",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionConnections,
+		Description: "List pending connection requests",
+	},
+	RequiresLogin: true,
+}
+
+var cmdAcceptInvitation = &commands.FullHandler{
+	Func: fnAcceptInvitation,
+	Name: "accept-invitation",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionConnections,
+		Description: "Accept a pending connection request",
+		Args:        "<_invitation ID_>",
+	},
+	RequiresLogin: true,
+}
+
+var cmdIgnoreInvitation = &commands.FullHandler{
+	Func: fnIgnoreInvitation,
+	Name: "ignore-invitation",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionConnections,
+		Description: "Ignore a pending connection request",
+		Args:        "<_invitation ID_>",
+	},
+	RequiresLogin: true,
+}
+
+var cmdSendInvitation = &commands.FullHandler{
+	Func: fnSendInvitation,
+	Name: "send-invitation",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionConnections,
+		Description: "Send a connection request, optionally with a note",
+		Args:        "<_profile ID_> [_note_]",
+	},
+	RequiresLogin: true,
+}
+
+var cmdImportExport = &commands.FullHandler{
+	Func: fnImportExport,
+	Name: "import-export",
+	Help: commands.HelpMeta{
+		Section:     commands.HelpSectionChats,
+		Description: "Import message history from a LinkedIn data export. Reply to the uploaded ZIP file with this command.",
+	},
+	RequiresLogin: true,
+}
+
+func getClient(ce *commands.Event) *LinkedInClient {
+	login := ce.User.GetDefaultLogin()
+	if login == nil {
+		ce.Reply("You're not logged in")
+		return nil
+	}
+	client, ok := login.Client.(*LinkedInClient)
+	if !ok || !client.IsLoggedIn() {
+		ce.Reply("You're not logged in")
+		return nil
+	}
+	return client
+}
+
+func fnInvitations(ce *commands.Event) {
+	client := getClient(ce)
+	if client == nil {
+		return
+	}
+	resp, err := client.client.GetReceivedInvitations(ce.Ctx, 0, invitationPageSize)
+	if err != nil {
+		ce.Reply("Failed to get connection requests: %v", err)
+		return
+	} else if len(resp.Elements) == 0 {
+		ce.Reply("You don't have any pending connection requests")
+		return
+	}
+	parts := make([]string, len(resp.Elements))
+	for i, view := range resp.Elements {
+		parts[i] = formatInvitation(view, false)
+	}
+	if resp.Paging.Total > len(resp.Elements) {
+		ce.Reply("Pending connection requests (showing %d of %d):\n\n%s", len(resp.Elements), resp.Paging.Total, strings.Join(parts, "\n\n"))
+	} else {
+		ce.Reply("Pending connection requests:\n\n%s", strings.Join(parts, "\n\n"))
+	}
+}
+
+func fnAcceptInvitation(ce *commands.Event) {
+	doInvitationAction(ce, "accept", "accepted", (*linkedingo.Client).AcceptInvitation)
+}
+
+func fnIgnoreInvitation(ce *commands.Event) {
+	doInvitationAction(ce, "ignore", "ignored", (*linkedingo.Client).IgnoreInvitation)
+}
+
+func doInvitationAction(ce *commands.Event, action, pastTense string, fn func(*linkedingo.Client, context.Context, linkedingo.Invitation) error) {
+	if len(ce.Args) != 1 {
+		ce.Reply("**Usage:** `%s-invitation <invitation ID>`", action)
+		return
+	}
+	client := getClient(ce)
+	if client == nil {
+		return
+	}
+	view, err := client.findReceivedInvitation(ce.Ctx, ce.Args[0])
+	if err != nil {
+		ce.Reply("Failed to get connection requests: %v", err)
+		return
+	} else if view == nil {
+		ce.Reply("Connection request `%s` not found", ce.Args[0])
+		return
+	}
+	err = fn(client.client, ce.Ctx, view.Invitation)
+	if err != nil {
+		ce.Reply("Failed to %s connection request: %v", action, err)
+		return
+	}
+	ce.Reply("Connection request from %s %s", view.Title.GetText(), pastTense)
+}
+
+func fnSendInvitation(ce *commands.Event) {
+	if len(ce.Args) == 0 {
+		ce.Reply("**Usage:** `send-invitation <profile ID> [note]`")
+		return
+	}
+	client := getClient(ce)
+	if client == nil {
+		return
+	}
+	profileURN := linkedingo.NewURN(ce.Args[0]).AsFsdProfile()
+	note := strings.TrimSpace(strings.TrimPrefix(ce.RawArgs, ce.Args[0]))
+	err := client.client.SendInvitation(ce.Ctx, profileURN, note)
+	if err != nil {
+		ce.Reply("Failed to send connection request: %v", err)
+		return
+	}
+	ce.Reply("Connection request sent")
+}
+
+func fnImportExport(ce *commands.Event) {
+	if ce.ReplyTo == "" {
+		ce.Reply("Upload your LinkedIn data export ZIP and reply to it with `import-export`")
+		return
+	}
+	client := getClient(ce)
+	if client == nil {
+		return
+	}
+	if !ce.Bridge.Config.Backfill.Enabled {
+		ce.Reply("Backfilling is disabled on this bridge")
+		return
+	} else if !ce.Bridge.Matrix.GetCapabilities().BatchSending {
+		// Without batch sending, the backfill queue doesn't run and old
+		// messages can't be inserted before the existing ones.
+		ce.Reply("Importing requires batch sending support from the homeserver")
+		return
+	}
+	evt, err := ce.Bot.GetEvent(ce.Ctx, ce.RoomID, ce.ReplyTo)
+	if err != nil {
+		ce.Reply("Failed to get replied-to event: %v", err)
+		return
+	}
+	_ = evt.Content.ParseRaw(evt.Type)
+	content := evt.Content.AsMessage()
+	if content.MsgType != event.MsgFile {
+		ce.Reply("The replied-to event isn't a file")
+		return
+	}
+	uri := content.URL
+	if content.File != nil {
+		uri = content.File.URL
+	}
+
+	var messages []linkedinexport.Message
+	err = ce.Bot.DownloadMediaToFile(ce.Ctx, uri, content.File, false, func(f *os.File) error {
+		stat, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		messages, err = linkedinexport.ReadZip(f, stat.Size())
+		return err
+	})
+	if err != nil {
+		ce.Reply("Failed to read export: %v", err)
+		return
+	}
+	threads := linkedinexport.GroupByConversation(messages)
+	ce.Reply("Found %d messages in %d conversations, importing...", len(messages), len(threads))
+
+	result, err := client.ImportExport(ce.Ctx, threads)
+	if err != nil {
+		ce.Reply("Failed to import export: %v", err)
+		return
+	}
+	reply := fmt.Sprintf("Queued %d messages in %d conversations for import.", result.Messages, result.Threads)
+	if result.AlreadyBridged > 0 {
+		reply += fmt.Sprintf(" %d messages were skipped because they're already bridged.", result.AlreadyBridged)
+	}
+	if result.MissingPortals > 0 {
+		reply += fmt.Sprintf(" %d conversations were skipped because they haven't been bridged.", result.MissingPortals)
+	}
+	if result.UnresolvedSenders > 0 {
+		reply += fmt.Sprintf(" %d messages were skipped because their sender couldn't be identified.", result.UnresolvedSenders)
+	}
+	ce.Reply(reply)
+}
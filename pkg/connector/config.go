@@ -36,6 +36,19 @@ type Config struct {
 		UpdateLimit int `yaml:"update_limit"`
 		CreateLimit int `yaml:"create_limit"`
 	} `yaml:"sync"`
+
+	Backfill struct {
+		FetchReactors           bool `yaml:"fetch_reactors"`
+		ReactorFetchMinCount    int  `yaml:"reactor_fetch_min_count"`
+		ReactorFetchConcurrency int  `yaml:"reactor_fetch_concurrency"`
+	} `yaml:"backfill"`
+
+	NotificationsRoom bool `yaml:"notifications_room"`
+	ReplySuggestions  bool `yaml:"reply_suggestions"`
+	UnicodeFormatting bool `yaml:"unicode_formatting"`
+	MediaBatchWindow  int  `yaml:"media_batch_window"`
+
+	SharedGroupPortals bool `yaml:"shared_group_portals"`
 }
 
 type umConfig Config
@@ -57,6 +70,14 @@ func upgradeConfig(helper up.Helper) {
 	helper.Copy(up.Str, "displayname_template")
 	helper.Copy(up.Int, "sync", "update_limit")
 	helper.Copy(up.Int, "sync", "create_limit")
+	helper.Copy(up.Bool, "backfill", "fetch_reactors")
+	helper.Copy(up.Int, "backfill", "reactor_fetch_min_count")
+	helper.Copy(up.Int, "backfill", "reactor_fetch_concurrency")
+	helper.Copy(up.Bool, "notifications_room")
+	helper.Copy(up.Bool, "reply_suggestions")
+	helper.Copy(up.Bool, "unicode_formatting")
+	helper.Copy(up.Int, "media_batch_window")
+	helper.Copy(up.Bool, "shared_group_portals")
 }
 
 func (lc *LinkedInConnector) GetConfig() (string, any, up.Upgrader) {
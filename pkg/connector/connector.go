@@ -18,9 +18,11 @@ package connector
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/commands"
 	"maunium.net/go/mautrix/bridgev2/networkid"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
@@ -41,9 +43,51 @@ func (l *LinkedInConnector) Init(bridge *bridgev2.Bridge) {
 }
 
 func (l *LinkedInConnector) Start(ctx context.Context) error {
+	if err := l.checkSharedGroupPortals(ctx); err != nil {
+		return err
+	}
+	l.Bridge.Commands.(*commands.Processor).AddHandlers(
+		cmdInvitations,
+		cmdAcceptInvitation,
+		cmdIgnoreInvitation,
+		cmdSendInvitation,
+		cmdImportExport,
+	)
 	return nil
 }
 
+const (
+	countMailboxGroupPortalsQuery = `
+		SELECT COUNT(*) FROM portal
+		WHERE bridge_id=$1 AND receiver='' AND id LIKE 'urn:li:msg_conversation:(%'
+	`
+	countSharedGroupPortalsQuery = `
+		SELECT COUNT(*) FROM portal
+		WHERE bridge_id=$1 AND receiver='' AND id LIKE 'urn:li:msg_conversation:%' AND id NOT LIKE 'urn:li:msg_conversation:(%'
+	`
+)
+
+// checkSharedGroupPortals refuses to start if shared_group_portals was
+// changed while the bridge has group portals from the other mode. Each login
+// has its own room for a group chat when the portals aren't shared, and those
+// rooms can't be merged, so there is no migration between the modes.
+func (l *LinkedInConnector) checkSharedGroupPortals(ctx context.Context) error {
+	query := countSharedGroupPortalsQuery
+	if l.sharedGroupPortals() {
+		query = countMailboxGroupPortalsQuery
+	}
+	var count int
+	err := l.Bridge.DB.QueryRow(ctx, query, l.Bridge.DB.BridgeID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count existing group portals: %w", err)
+	} else if count == 0 {
+		return nil
+	} else if l.sharedGroupPortals() {
+		return fmt.Errorf("shared_group_portals can't be enabled on a bridge with %d existing group portals which aren't shared", count)
+	}
+	return fmt.Errorf("shared_group_portals can't be disabled on a bridge with %d existing shared group portals", count)
+}
+
 func (l *LinkedInConnector) LoadUserLogin(ctx context.Context, login *bridgev2.UserLogin) error {
 	login.Client = NewLinkedInClient(ctx, l, login)
 	return nil
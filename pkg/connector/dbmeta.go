@@ -17,7 +17,11 @@
 package connector
 
 import (
+	"context"
+
+	"go.mau.fi/util/jsontime"
 	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
 
 	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
 )
@@ -25,7 +29,9 @@ import (
 func (lc *LinkedInConnector) GetDBMetaTypes() database.MetaTypes {
 	return database.MetaTypes{
 		Reaction: nil,
-		Portal:   nil,
+		Portal: func() any {
+			return &PortalMetadata{}
+		},
 		Message: func() any {
 			return &MessageMetadata{}
 		},
@@ -41,10 +47,45 @@ type UserLoginMetadata struct {
 	XLITrack               string                      `json:"x_li_track,omitempty"`
 	XLIPageInstance        string                      `json:"x_li_page_instance,omitempty"`
 	ConversationsSyncToken string                      `json:"conversations_sync_token,omitempty"`
+	// OutboundQueue contains the Matrix messages which are waiting to be
+	// sent to LinkedIn.
+	OutboundQueue []*QueuedMessage `json:"outbound_queue,omitempty"`
+}
+
+func (l *LinkedInClient) getLoginMetadata() *UserLoginMetadata {
+	return l.userLogin.Metadata.(*UserLoginMetadata)
+}
+
+// updateLoginMetadata changes the login metadata and saves it. Changes are
+// serialized, so that saving doesn't read the metadata while it's being
+// changed by another goroutine.
+func (l *LinkedInClient) updateLoginMetadata(ctx context.Context, update func(meta *UserLoginMetadata)) error {
+	l.metadataLock.Lock()
+	defer l.metadataLock.Unlock()
+	update(l.getLoginMetadata())
+	return l.userLogin.Save(ctx)
+}
+
+type PortalMetadata struct {
+	// LastReadAt is when each login last read the conversation.
+	LastReadAt map[networkid.UserLoginID]jsontime.UnixMilli `json:"last_read_at,omitempty"`
+	// SeenReceipts is the latest seen receipt of each participant.
+	SeenReceipts map[networkid.UserID]SeenReceiptMeta `json:"seen_receipts,omitempty"`
+	// CreatedByInvite is set for DMs which were started by inviting a ghost
+	// to a new Matrix room. Inviting more ghosts turns them into a group.
+	CreatedByInvite bool `json:"created_by_invite,omitempty"`
+}
+
+type SeenReceiptMeta struct {
+	MessageID networkid.MessageID `json:"message_id"`
+	SeenAt    jsontime.UnixMilli  `json:"seen_at"`
 }
 
 type MessageMetadata struct {
 	DirectMediaMeta *DirectMediaMeta `json:"direct_media_meta,omitempty"`
+	// SplitParts are the IDs of the additional LinkedIn messages that a long
+	// Matrix message was split into.
+	SplitParts []networkid.MessageID `json:"split_parts,omitempty"`
 }
 
 type DirectMediaMeta struct {
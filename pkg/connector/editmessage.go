@@ -0,0 +1,94 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+var ErrEditUnsupportedMsgType = bridgev2.WrapErrorInStatus(errors.New("this type of message can't be edited on LinkedIn")).
+	WithIsCertain(true).WithSendNotice(true).WithErrorAsMessage().WithErrorReason(event.MessageStatusUnsupported)
+
+var ErrEditAttachment = bridgev2.WrapErrorInStatus(errors.New("attachments can't be changed on LinkedIn, only the caption can be edited")).
+	WithIsCertain(true).WithSendNotice(true).WithErrorAsMessage().WithErrorReason(event.MessageStatusUnsupported)
+
+// isEditableMsgType returns true if LinkedIn can apply an edit with the given
+// content. The text of messages can be edited, but media can only have their
+// caption edited.
+func isEditableMsgType(msgType event.MessageType) bool {
+	switch msgType {
+	case event.MsgText, event.MsgNotice, event.MsgEmote,
+		event.MsgImage, event.MsgVideo, event.MsgAudio, event.MsgFile,
+		event.MsgBeeperGallery:
+		return true
+	default:
+		return false
+	}
+}
+
+// mediaURLs returns the URLs of the attachments in a message.
+func mediaURLs(content *event.MessageEventContent) (urls []id.ContentURIString) {
+	if content.MsgType == event.MsgBeeperGallery {
+		for _, image := range content.BeeperGalleryImages {
+			urls = append(urls, mediaURLs(image)...)
+		}
+	} else if content.MsgType.IsMedia() {
+		if content.File != nil {
+			urls = append(urls, content.File.URL)
+		} else {
+			urls = append(urls, content.URL)
+		}
+	}
+	return
+}
+
+// checkEditable returns an error if LinkedIn can't apply the edit, either
+// because the message is too old or because the edit changes the attachments
+// of the message rather than its text or caption.
+func (l *LinkedInClient) checkEditable(ctx context.Context, msg *bridgev2.MatrixEdit) error {
+	if time.Since(msg.EditTarget.Timestamp) > MaxEditAge {
+		return bridgev2.ErrEditTargetTooOld
+	} else if !isEditableMsgType(msg.Content.MsgType) {
+		return ErrEditUnsupportedMsgType
+	}
+
+	intent := l.userLogin.Bridge.Matrix.GhostIntent(l.userID)
+	evt, err := intent.GetEvent(ctx, msg.Portal.MXID, msg.EditTarget.MXID)
+	if err != nil || evt == nil {
+		// Without the original event, only the text of the edit can be
+		// checked, so any attachment changes are ignored.
+		zerolog.Ctx(ctx).Warn().Err(err).
+			Stringer("event_id", msg.EditTarget.MXID).
+			Msg("Failed to get edit target event to check attachments")
+		return nil
+	}
+	original := evt.Content.AsMessage()
+	if evt.Type == event.EventSticker || !isEditableMsgType(original.MsgType) {
+		return ErrEditUnsupportedMsgType
+	} else if !slices.Equal(mediaURLs(original), mediaURLs(msg.Content)) {
+		return ErrEditAttachment
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"time"
+
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// This file exposes unexported helpers to the external connector_test
+// package.
+
+func NewRealtimeEventDedup(size int) *realtimeEventDedup {
+	return newRealtimeEventDedup(size)
+}
+
+func NewRealtimeReorderBuffer(window time.Duration, handle func(ctx context.Context, msg linkedingo.Message)) *realtimeReorderBuffer {
+	return newRealtimeReorderBuffer(window, handle)
+}
+
+var RetryDeferred = retryDeferred
+
+// SetDeferredRetryInterval changes the interval between deferred retries and
+// returns a function which restores the previous interval.
+func SetDeferredRetryInterval(interval time.Duration) func() {
+	prev := deferredRetryInterval
+	deferredRetryInterval = interval
+	return func() { deferredRetryInterval = prev }
+}
+
+var IsRetriableSendError = isRetriableSendError
+
+func (qm *QueuedMessage) RetryDelay() time.Duration {
+	return qm.retryDelay()
+}
+
+// OutboundQueue wraps the outbound queue operations with the queue lock like
+// the outbound worker uses them.
+type OutboundQueue struct {
+	oq *outboundQueue
+}
+
+func NewOutboundQueue() *OutboundQueue {
+	return &OutboundQueue{oq: newOutboundQueue()}
+}
+
+func (q *OutboundQueue) Push(qm *QueuedMessage) {
+	q.oq.lock.Lock()
+	defer q.oq.lock.Unlock()
+	q.oq.push(qm)
+}
+
+func (q *OutboundQueue) Peek(portalKey networkid.PortalKey) *QueuedMessage {
+	q.oq.lock.Lock()
+	defer q.oq.lock.Unlock()
+	return q.oq.peek(portalKey)
+}
+
+func (q *OutboundQueue) Pop(portalKey networkid.PortalKey) {
+	q.oq.lock.Lock()
+	defer q.oq.lock.Unlock()
+	q.oq.pop(portalKey)
+}
+
+func (q *OutboundQueue) HasPending(portalKey networkid.PortalKey) bool {
+	return q.oq.hasPending(portalKey)
+}
+
+func (q *OutboundQueue) Snapshot() []*QueuedMessage {
+	q.oq.lock.Lock()
+	defer q.oq.lock.Unlock()
+	return q.oq.snapshot()
+}
@@ -3,10 +3,12 @@ package connector
 import (
 	"context"
 	"errors"
+	"maps"
 	"time"
 
 	"github.com/rs/zerolog"
 	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
 	"maunium.net/go/mautrix/bridgev2/networkid"
 	"maunium.net/go/mautrix/bridgev2/simplevent"
 	"maunium.net/go/mautrix/bridgev2/status"
@@ -31,8 +33,9 @@ func (l *LinkedInClient) onBadCredentials(ctx context.Context, err error) {
 		Message:    err.Error(),
 	})
 	if errors.Is(err, linkedingo.ErrTokenInvalidated) {
-		l.userLogin.Metadata.(*UserLoginMetadata).Cookies.Clear()
-		err = l.userLogin.Save(ctx)
+		err = l.updateLoginMetadata(ctx, func(meta *UserLoginMetadata) {
+			meta.Cookies.Clear()
+		})
 		if err != nil {
 			zerolog.Ctx(ctx).Err(err).Msg("failed to clear cookies after token invalidation")
 		}
@@ -53,7 +56,7 @@ func (l *LinkedInClient) onUnknownError(ctx context.Context, err error) {
 	go l.Disconnect()
 }
 
-func (l *LinkedInClient) onDecoratedEvent(ctx context.Context, decoratedEvent *linkedingo.DecoratedEvent) {
+func (l *LinkedInClient) onDecoratedEvent(ctx context.Context, decoratedEvent *linkedingo.DecoratedEvent) bool {
 	log := zerolog.Ctx(ctx).With().
 		Str("decorated_event_id", decoratedEvent.ID).
 		Stringer("topic", decoratedEvent.Topic).
@@ -61,32 +64,16 @@ func (l *LinkedInClient) onDecoratedEvent(ctx context.Context, decoratedEvent *l
 		Logger()
 	log.Debug().Msg("Received decorated event")
 
-	data := decoratedEvent.Payload.Data
-
-	// The topics are always of the form "urn:li-realtime:TOPIC_NAME:<topic_dependent>"
-	switch decoratedEvent.Topic.NthPrefixPart(2) {
-	case linkedingo.RealtimeEventTopicConversations, linkedingo.RealtimeEventTopicTabBadgeUpdate:
-		var conv *linkedingo.Conversation
-		if data.DecoratedConversation != nil {
-			conv = &data.DecoratedConversation.Result
+	if decoratedEvent.ID != "" {
+		if l.seenRealtimeEvents.Seen(decoratedEvent.ID) {
+			log.Debug().Msg("Ignoring duplicate decorated event")
+			return false
 		}
-		l.onRealtimeConversations(ctx, conv)
-	case linkedingo.RealtimeEventTopicConversationDelete:
-		l.onRealtimeConversationDelete(ctx, decoratedEvent.Payload.Data.DecoratedConversationDelete.Result)
-	case linkedingo.RealtimeEventTopicMessages:
-		l.onRealtimeMessage(ctx, decoratedEvent.Payload.Data.DecoratedMessage.Result)
-	case linkedingo.RealtimeEventTopicTypingIndicators:
-		l.onRealtimeTypingIndicator(decoratedEvent)
-	case linkedingo.RealtimeEventTopicMessageSeenReceipts:
-		l.onRealtimeMessageSeenReceipts(ctx, decoratedEvent.Payload.Data.DecoratedSeenReceipt.Result)
-	case linkedingo.RealtimeEventTopicMessageReactionSummaries:
-		l.onRealtimeReactionSummaries(ctx, decoratedEvent.Payload.Data.DecoratedReactionSummary.Result)
-	default:
-		log.Warn().Msg("Unsupported event topic")
 	}
+	return true
 }
 
-func (l *LinkedInClient) onRealtimeConversations(ctx context.Context, conv *linkedingo.Conversation) {
+func (l *LinkedInClient) onRealtimeConversations(ctx context.Context, _ *linkedingo.DecoratedEvent, conv *linkedingo.Conversation) {
 	if conv != nil {
 		convs := []linkedingo.Conversation{*conv}
 		l.handleConversations(ctx, convs)
@@ -96,19 +83,28 @@ func (l *LinkedInClient) onRealtimeConversations(ctx context.Context, conv *link
 
 }
 
-func (l *LinkedInClient) onRealtimeConversationDelete(ctx context.Context, conv linkedingo.Conversation) {
+func (l *LinkedInClient) onRealtimeConversationDelete(ctx context.Context, _ *linkedingo.DecoratedEvent, conv *linkedingo.Conversation) {
+	if conv == nil {
+		return
+	}
 	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.ChatDelete{
 		EventMeta: simplevent.EventMeta{
 			Type: bridgev2.RemoteEventChatDelete,
 			LogContext: func(c zerolog.Context) zerolog.Context {
 				return c.Stringer("entity_urn", conv.EntityURN)
 			},
-			PortalKey: l.makePortalKey(conv),
+			PortalKey: l.makePortalKey(*conv),
 		},
 	})
 }
 
-func (l *LinkedInClient) onRealtimeMessage(ctx context.Context, msg linkedingo.Message) {
+func (l *LinkedInClient) onRealtimeMessage(ctx context.Context, _ *linkedingo.DecoratedEvent, msg *linkedingo.Message) {
+	if msg != nil {
+		l.messageBuffer.Add(ctx, *msg)
+	}
+}
+
+func (l *LinkedInClient) handleRealtimeMessage(ctx context.Context, msg linkedingo.Message) {
 	log := zerolog.Ctx(ctx)
 	log.Trace().
 		Str("body_text", msg.Body.Text).
@@ -131,6 +127,8 @@ func (l *LinkedInClient) onRealtimeMessage(ctx context.Context, msg linkedingo.M
 		CreatePortal: true,
 	}
 
+	l.stopRemoteTyping(meta.PortalKey, meta.Sender)
+
 	chatInfo, _ := l.conversationToChatInfo(msg.Conversation)
 	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.ChatResync{
 		EventMeta:       meta.WithType(bridgev2.RemoteEventChatResync),
@@ -139,8 +137,9 @@ func (l *LinkedInClient) onRealtimeMessage(ctx context.Context, msg linkedingo.M
 	})
 
 	evt := simplevent.Message[linkedingo.Message]{
-		ID:                 msg.MessageID(),
-		TargetMessage:      msg.MessageID(),
+		ID:                 l.messageID(meta.PortalKey, msg.EntityURN),
+		TargetMessage:      l.messageID(meta.PortalKey, msg.EntityURN),
+		TransactionID:      networkid.TransactionID(msg.OriginToken),
 		Data:               msg,
 		ConvertMessageFunc: l.convertToMatrix,
 		ConvertEditFunc:    l.convertEditToMatrix,
@@ -153,47 +152,55 @@ func (l *LinkedInClient) onRealtimeMessage(ctx context.Context, msg linkedingo.M
 	case linkedingo.MessageBodyRenderFormatRecalled:
 		l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.MessageRemove{
 			EventMeta:     meta.WithType(bridgev2.RemoteEventMessageRemove),
-			TargetMessage: msg.MessageID(),
+			TargetMessage: l.messageID(meta.PortalKey, msg.EntityURN),
 		})
 		return
 	case linkedingo.MessageBodyRenderFormatSystem:
-		log.Info().Msg("Ignoring system message")
-		return
+		if change := l.parseSystemMessage(msg); change != nil {
+			l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.ChatInfoChange{
+				EventMeta:      meta.WithType(bridgev2.RemoteEventChatInfoChange),
+				ChatInfoChange: change,
+			})
+			return
+		}
+		// Other system messages are sent as notices by the bridge bot.
+		evt.EventMeta = meta.WithType(bridgev2.RemoteEventMessage)
+		evt.Sender = bridgev2.EventSender{}
+		evt.ConvertMessageFunc = l.convertSystemMessageToMatrix
 	default:
 		log.Warn().Str("message_body_render_format", string(msg.MessageBodyRenderFormat)).Msg("Unknown render format")
 	}
 	l.main.Bridge.QueueRemoteEvent(l.userLogin, &evt)
 }
 
-func (l *LinkedInClient) onRealtimeTypingIndicator(decoratedEvent *linkedingo.DecoratedEvent) {
-	typingIndicator := decoratedEvent.Payload.Data.DecoratedTypingIndicator.Result
-	meta := simplevent.EventMeta{
-		Type: bridgev2.RemoteEventTyping,
-		LogContext: func(c zerolog.Context) zerolog.Context {
-			return c.
-				Stringer("conversation_urn", typingIndicator.Conversation.EntityURN).
-				Stringer("typing_participant_urn", typingIndicator.TypingParticipant.EntityURN)
-		},
-		PortalKey: l.makePortalKey(typingIndicator.Conversation),
-		Sender:    l.makeSender(typingIndicator.TypingParticipant),
-		Timestamp: decoratedEvent.LeftServerAt.Time,
+func (l *LinkedInClient) onRealtimeMessageSeenReceipts(ctx context.Context, _ *linkedingo.DecoratedEvent, receipt *linkedingo.SeenReceipt) {
+	if receipt == nil {
+		return
 	}
-
-	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Typing{
-		EventMeta: meta,
-		Timeout:   10 * time.Second,
-		Type:      bridgev2.TypingTypeText,
+	retryDeferred(ctx, "seen_receipt", func(ctx context.Context) bool {
+		return l.handleSeenReceipt(ctx, *receipt)
 	})
 }
 
-func (l *LinkedInClient) onRealtimeMessageSeenReceipts(ctx context.Context, receipt linkedingo.SeenReceipt) {
+func (l *LinkedInClient) handleSeenReceipt(ctx context.Context, receipt linkedingo.SeenReceipt) bool {
+	part, ok := l.sendSeenReceipt(ctx, receipt)
+	if part != nil {
+		l.storeSeenReceipts(part.Room, receipt)
+	}
+	return ok
+}
+
+// sendSeenReceipt bridges a seen receipt if the message has been bridged. It
+// returns false if the receipt should be retried later.
+func (l *LinkedInClient) sendSeenReceipt(ctx context.Context, receipt linkedingo.SeenReceipt) (*database.Message, bool) {
 	log := zerolog.Ctx(ctx)
-	part, err := l.main.Bridge.DB.Message.GetLastPartByID(ctx, l.userLogin.ID, receipt.Message.MessageID())
+	part, err := l.getMessagePartByURN(ctx, receipt.Message.EntityURN, l.main.Bridge.DB.Message.GetLastPartByID)
 	if err != nil {
 		log.Err(err).Msg("failed to get read message")
+		return nil, true
 	} else if part == nil {
-		log.Warn().Msg("couldn't find read message")
-		return
+		log.Debug().Msg("couldn't find read message")
+		return nil, false
 	}
 	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Receipt{
 		EventMeta: simplevent.EventMeta{
@@ -208,15 +215,28 @@ func (l *LinkedInClient) onRealtimeMessageSeenReceipts(ctx context.Context, rece
 			Sender:    l.makeSender(receipt.SeenByParticipant),
 			Timestamp: receipt.SeenAt.Time,
 		},
-		LastTarget: receipt.Message.MessageID(),
+		LastTarget: part.ID,
+	})
+	return part, true
+}
+
+func (l *LinkedInClient) onRealtimeReactionSummaries(ctx context.Context, _ *linkedingo.DecoratedEvent, summary *linkedingo.RealtimeReactionSummary) {
+	if summary == nil {
+		return
+	}
+	retryDeferred(ctx, "reaction_summary", func(ctx context.Context) bool {
+		return l.handleReactionSummary(ctx, *summary)
 	})
 }
 
-func (l *LinkedInClient) onRealtimeReactionSummaries(ctx context.Context, summary linkedingo.RealtimeReactionSummary) {
-	messageData, err := l.main.Bridge.DB.Message.GetFirstPartByID(context.TODO(), l.userLogin.ID, summary.Message.MessageID())
+func (l *LinkedInClient) handleReactionSummary(ctx context.Context, summary linkedingo.RealtimeReactionSummary) bool {
+	messageData, err := l.getMessagePartByURN(ctx, summary.Message.EntityURN, l.main.Bridge.DB.Message.GetFirstPartByID)
 	if err != nil {
 		zerolog.Ctx(ctx).Err(err).Msg("failed to get reacted to message")
-		return
+		return true
+	} else if messageData == nil {
+		zerolog.Ctx(ctx).Debug().Msg("couldn't find reacted to message")
+		return false
 	}
 
 	meta := simplevent.EventMeta{
@@ -238,6 +258,38 @@ func (l *LinkedInClient) onRealtimeReactionSummaries(ctx context.Context, summar
 		EventMeta:     meta,
 		EmojiID:       networkid.EmojiID(summary.ReactionSummary.Emoji),
 		Emoji:         summary.ReactionSummary.Emoji,
-		TargetMessage: summary.Message.MessageID(),
+		TargetMessage: messageData.ID,
 	})
+	return true
+}
+
+func (l *LinkedInClient) connectedState() status.BridgeState {
+	state := status.BridgeState{StateEvent: status.StateConnected}
+	l.badgeCountsLock.RLock()
+	defer l.badgeCountsLock.RUnlock()
+	if len(l.badgeCounts) > 0 {
+		state.Info = map[string]any{
+			"unread_messaging":     l.badgeCounts[linkedingo.BadgingItemMessaging],
+			"unread_notifications": l.badgeCounts[linkedingo.BadgingItemNotifications],
+			"unread_my_network":    l.badgeCounts[linkedingo.BadgingItemMyNetwork],
+		}
+	}
+	return state
+}
+
+func (l *LinkedInClient) onRealtimeBadgeCounts(ctx context.Context, _ *linkedingo.DecoratedEvent, evt *linkedingo.RealtimeBadgingItemCountsEvent) {
+	if evt == nil || len(evt.BadgingItemCounts) == 0 {
+		return
+	}
+	counts := evt.Counts()
+	zerolog.Ctx(ctx).Debug().Any("badge_counts", counts).Msg("Badge counts updated")
+	l.badgeCountsLock.Lock()
+	if l.badgeCounts == nil {
+		l.badgeCounts = map[linkedingo.BadgingItem]int{}
+	}
+	maps.Copy(l.badgeCounts, counts)
+	l.badgeCountsLock.Unlock()
+	if l.userLogin.BridgeState.GetPrevUnsent().StateEvent == status.StateConnected {
+		l.userLogin.BridgeState.Send(l.connectedState())
+	}
 }
@@ -42,6 +42,7 @@ var (
 	_ bridgev2.DeleteChatHandlingNetworkAPI  = (*LinkedInClient)(nil)
 	_ bridgev2.EditHandlingNetworkAPI        = (*LinkedInClient)(nil)
 	_ bridgev2.MembershipHandlingNetworkAPI  = (*LinkedInClient)(nil)
+	_ bridgev2.PowerLevelHandlingNetworkAPI  = (*LinkedInClient)(nil)
 	_ bridgev2.ReactionHandlingNetworkAPI    = (*LinkedInClient)(nil)
 	_ bridgev2.RedactionHandlingNetworkAPI   = (*LinkedInClient)(nil)
 	_ bridgev2.ReadReceiptHandlingNetworkAPI = (*LinkedInClient)(nil)
@@ -49,6 +50,18 @@ var (
 	_ bridgev2.TypingHandlingNetworkAPI      = (*LinkedInClient)(nil)
 )
 
+// formatEmote handles emotes by adding a "*" and the user's name to the
+// message. Relayed messages have already been formatted with the sender's name.
+func (l *LinkedInClient) formatEmote(content *event.MessageEventContent, origSender *bridgev2.OrigSender) {
+	if content.MsgType != event.MsgEmote || origSender != nil {
+		return
+	}
+	content.EnsureHasHTML()
+	content.Body = fmt.Sprintf("* %s %s", l.userLogin.RemoteName, content.Body)
+	content.FormattedBody = fmt.Sprintf(`* <a href="https://matrix.to/#/%s">%s</a> %s`, l.userLogin.UserMXID, l.userLogin.RemoteName, content.FormattedBody)
+	content.Mentions = &event.Mentions{UserIDs: []id.UserID{l.userLogin.UserMXID}}
+}
+
 func getMediaFilename(content *event.MessageEventContent) (filename string) {
 	if content.FileName != "" {
 		filename = content.FileName
@@ -64,122 +77,36 @@ var ErrNotLoggedIn = bridgev2.WrapErrorInStatus(fmt.Errorf("you're %w", bridgev2
 func (l *LinkedInClient) HandleMatrixMessage(ctx context.Context, msg *bridgev2.MatrixMessage) (*bridgev2.MatrixMessageResponse, error) {
 	if !l.IsLoggedIn() {
 		return nil, ErrNotLoggedIn
+	} else if isNotificationsPortal(msg.Portal) {
+		return nil, ErrNotificationsRoomReadOnly
 	}
-	conversationURN := linkedingo.NewURN(msg.Portal.ID)
-
-	// Handle emotes by adding a "*" and the user's name to the message
-	if msg.Content.MsgType == event.MsgEmote {
-		msg.Content.EnsureHasHTML()
-		msg.Content.Body = fmt.Sprintf("* %s %s", l.userLogin.RemoteName, msg.Content.Body)
-		msg.Content.FormattedBody = fmt.Sprintf(`* <a href="https://matrix.to/#/%s">%s</a> %s`, l.userLogin.UserMXID, l.userLogin.RemoteName, msg.Content.FormattedBody)
-		msg.Content.Mentions = &event.Mentions{UserIDs: []id.UserID{l.userLogin.UserMXID}}
-	}
-
-	var renderContent []linkedingo.SendRenderContent
-	var progressiveStreamsContent []linkedingo.SendProgressiveStreams
-	var urls []linkedingo.SendURL
-	var artifacts []linkedingo.SendArtifacts
+	l.stopLocalTyping(msg.Portal.PortalKey)
+	conversationURN := l.conversationURN(msg.Portal.ID)
 
-	if msg.Content.MsgType.IsMedia() {
-		err := l.main.Bridge.Bot.DownloadMediaToFile(ctx, msg.Content.URL, msg.Content.File, false, func(f *os.File) error {
-
-			attachmentType := linkedingo.MediaUploadTypeFileAttachment
-			switch msg.Content.MsgType {
-			case event.MsgImage:
-				attachmentType = linkedingo.MediaUploadTypePhotoAttachment
-			case event.MsgVideo:
-				attachmentType = linkedingo.MediaUploadTypeVideoAttachment
-			case event.MsgAudio:
-				attachmentType = linkedingo.MediaUploadTypeVoiceMessage
-			}
-
-			filename := getMediaFilename(msg.Content)
-			mime := msg.Content.GetInfo().MimeType
-			if msg.Content.MSC3245Voice != nil && mime != "audio/mp4" {
-				if !ffmpeg.Supported() {
-					return errors.New("ffmpeg is required to send voice message")
-				}
-				outPath, err := ffmpeg.ConvertPath(ctx, f.Name(), ".m4a", []string{}, []string{"-c:a", "aac", "-b:a", "32k"}, false)
-				if err != nil {
-					return err
-				}
-				f, err = os.Open(outPath)
-				if err != nil {
-					return err
-				}
-				fileInfo, err := os.Stat(f.Name())
-				if err != nil {
-					return err
-				}
-				msg.Content.Info.Size = int(fileInfo.Size())
-				defer f.Close()
-			}
+	l.formatEmote(msg.Content, msg.OrigSender)
 
-			urn, err := l.client.UploadMedia(ctx, attachmentType, filename, msg.Content.Info.MimeType, msg.Content.Info.Size, f)
+	var renderContent []linkedingo.SendRenderContent
+	bodyContent := msg.Content
+	if msg.Content.MsgType == event.MsgBeeperGallery {
+		for _, image := range msg.Content.BeeperGalleryImages {
+			rc, err := l.uploadMatrixMedia(ctx, image)
 			if err != nil {
-				return err
+				return nil, err
 			}
-
-			switch msg.Content.MsgType {
-			//handle video attachment
-			case event.MsgVideo:
-				id := uuid.New()
-				blob_string := "blob:https://www.linkedin.com/" + id.String()
-
-				urls = append(urls, linkedingo.SendURL{
-					URL: blob_string,
-				})
-
-				progressiveStreamsContent = append(progressiveStreamsContent, linkedingo.SendProgressiveStreams{
-					BitRate:            0,
-					Height:             0,
-					MediaType:          msg.Content.Info.MimeType,
-					Size:               msg.Content.Info.Size,
-					Width:              0,
-					StreamingLocations: urls,
-				})
-
-				artifacts = append(artifacts, linkedingo.SendArtifacts{
-					Width:  0,
-					Height: 0,
-				})
-
-				thumbnails := linkedingo.SendThumbnail{
-					RootUrl:   "",
-					Artifacts: artifacts,
-				}
-
-				renderContent = append(renderContent, linkedingo.SendRenderContent{
-					Video: &linkedingo.SendVideo{
-						Media:              urn,
-						Thumbnail:          thumbnails,
-						TrackingID:         urn,
-						ProgressiveStreams: progressiveStreamsContent,
-					},
-				})
-			case event.MsgAudio:
-				renderContent = append(renderContent, linkedingo.SendRenderContent{
-					Audio: &linkedingo.SendAudio{
-						AssetURN: urn,
-						ByteSize: msg.Content.Info.Size,
-					},
-				})
-			default:
-				renderContent = append(renderContent, linkedingo.SendRenderContent{
-					File: &linkedingo.SendFile{
-						AssetURN:  urn,
-						Name:      filename,
-						MediaType: msg.Content.Info.MimeType,
-						ByteSize:  msg.Content.Info.Size,
-					},
-				})
-			}
-			return nil
-		})
+			renderContent = append(renderContent, rc)
+		}
+		bodyContent = galleryCaption(msg.Content)
+	} else if msg.Content.MsgType.IsMedia() {
+		rc, err := l.uploadMatrixMedia(ctx, msg.Content)
 		if err != nil {
 			return nil, err
 		}
+		if l.canBatchMedia(msg) {
+			return l.addToMediaBatch(ctx, msg, rc, matrixfmt.Parse(ctx, l.matrixParser, msg.Content))
+		}
+		renderContent = append(renderContent, rc)
 	}
+	l.flushPortalMediaBatch(msg.Portal.PortalKey)
 
 	if msg.ReplyTo != nil {
 		intent := l.userLogin.Bridge.Matrix.GhostIntent(l.userID)
@@ -193,7 +120,7 @@ func (l *LinkedInClient) HandleMatrixMessage(ctx context.Context, msg *bridgev2.
 				RepliedMessageContent: &linkedingo.SendRepliedMessage{
 					OriginalSenderURN:  linkedingo.NewURN(string(msg.ReplyTo.SenderID)).WithPrefix("urn:li:msg_messagingParticipant:urn:li:fsd_profile"),
 					OriginalSendAt:     jsontime.UnixMilli{Time: msg.ReplyTo.Timestamp},
-					OriginalMessageURN: linkedingo.NewURN(string(msg.ReplyTo.ID)),
+					OriginalMessageURN: l.messageURN(msg.ReplyTo.ID),
 					MessageBody: linkedingo.AttributedText{
 						Text: body.Text,
 					},
@@ -205,34 +132,202 @@ func (l *LinkedInClient) HandleMatrixMessage(ctx context.Context, msg *bridgev2.
 	if transactionID == "" {
 		transactionID = uuid.NewString()
 	}
-	resp, err := l.client.SendMessage(ctx, conversationURN, matrixfmt.Parse(ctx, l.matrixParser, msg.Content), renderContent, transactionID)
-	if err != nil {
+	body := matrixfmt.Parse(ctx, l.matrixParser, bodyContent)
+	if l.outbound.hasPending(msg.Portal.PortalKey) {
+		// Keep the order of messages in the chat by sending this one after
+		// the ones which are already waiting to be retried.
+		return l.queueOutboundMessage(ctx, msg, body, renderContent, transactionID, nil)
+	}
+	resp, parts, err := l.sendMessageParts(ctx, msg, conversationURN, body, renderContent, transactionID)
+	if isRetriableSendError(err) && ctx.Err() == nil {
+		// Retries use the same transaction IDs, so LinkedIn won't duplicate
+		// the parts which were already sent.
+		return l.queueOutboundMessage(ctx, msg, body, renderContent, transactionID, err)
+	} else if err != nil {
+		l.recallMessageParts(ctx, resp, parts)
 		return nil, err
 	}
 	return &bridgev2.MatrixMessageResponse{
 		DB: &database.Message{
-			ID:        resp.Data.MessageID(),
+			ID:        l.messageID(msg.Portal.PortalKey, resp.Data.EntityURN),
 			MXID:      msg.Event.ID,
 			Room:      msg.Portal.PortalKey,
 			SenderID:  l.userID,
 			Timestamp: resp.Data.DeliveredAt.Time,
+			Metadata:  &MessageMetadata{SplitParts: l.saveSplitParts(ctx, msg.Event.Sender, parts)},
 		},
 		StreamOrder: resp.Data.DeliveredAt.UnixMilli(),
 	}, nil
 }
 
+// uploadMatrixMedia uploads the media in a Matrix message to LinkedIn and
+// returns the render content for sending it.
+func (l *LinkedInClient) uploadMatrixMedia(ctx context.Context, content *event.MessageEventContent) (rc linkedingo.SendRenderContent, err error) {
+	var progressiveStreamsContent []linkedingo.SendProgressiveStreams
+	var urls []linkedingo.SendURL
+	var artifacts []linkedingo.SendArtifacts
+
+	err = l.main.Bridge.Bot.DownloadMediaToFile(ctx, content.URL, content.File, false, func(f *os.File) error {
+
+		attachmentType := linkedingo.MediaUploadTypeFileAttachment
+		switch content.MsgType {
+		case event.MsgImage:
+			attachmentType = linkedingo.MediaUploadTypePhotoAttachment
+		case event.MsgVideo:
+			attachmentType = linkedingo.MediaUploadTypeVideoAttachment
+		case event.MsgAudio:
+			attachmentType = linkedingo.MediaUploadTypeVoiceMessage
+		}
+
+		filename := getMediaFilename(content)
+		mime := content.GetInfo().MimeType
+		if content.MSC3245Voice != nil && mime != "audio/mp4" {
+			if !ffmpeg.Supported() {
+				return errors.New("ffmpeg is required to send voice message")
+			}
+			outPath, err := ffmpeg.ConvertPath(ctx, f.Name(), ".m4a", []string{}, []string{"-c:a", "aac", "-b:a", "32k"}, false)
+			if err != nil {
+				return err
+			}
+			f, err = os.Open(outPath)
+			if err != nil {
+				return err
+			}
+			fileInfo, err := os.Stat(f.Name())
+			if err != nil {
+				return err
+			}
+			content.Info.Size = int(fileInfo.Size())
+			defer f.Close()
+		}
+
+		urn, err := l.client.UploadMedia(ctx, attachmentType, filename, content.Info.MimeType, content.Info.Size, f)
+		if err != nil {
+			return err
+		}
+
+		switch content.MsgType {
+		//handle video attachment
+		case event.MsgVideo:
+			id := uuid.New()
+			blob_string := "blob:https://www.linkedin.com/" + id.String()
+
+			urls = append(urls, linkedingo.SendURL{
+				URL: blob_string,
+			})
+
+			progressiveStreamsContent = append(progressiveStreamsContent, linkedingo.SendProgressiveStreams{
+				BitRate:            0,
+				Height:             0,
+				MediaType:          content.Info.MimeType,
+				Size:               content.Info.Size,
+				Width:              0,
+				StreamingLocations: urls,
+			})
+
+			artifacts = append(artifacts, linkedingo.SendArtifacts{
+				Width:  0,
+				Height: 0,
+			})
+
+			thumbnails := linkedingo.SendThumbnail{
+				RootUrl:   "",
+				Artifacts: artifacts,
+			}
+
+			rc = linkedingo.SendRenderContent{
+				Video: &linkedingo.SendVideo{
+					Media:              urn,
+					Thumbnail:          thumbnails,
+					TrackingID:         urn,
+					ProgressiveStreams: progressiveStreamsContent,
+				},
+			}
+		case event.MsgAudio:
+			rc = linkedingo.SendRenderContent{
+				Audio: &linkedingo.SendAudio{
+					AssetURN: urn,
+					ByteSize: content.Info.Size,
+				},
+			}
+		default:
+			rc = linkedingo.SendRenderContent{
+				File: &linkedingo.SendFile{
+					AssetURN:  urn,
+					Name:      filename,
+					MediaType: content.Info.MimeType,
+					ByteSize:  content.Info.Size,
+				},
+			}
+		}
+		return nil
+	})
+	return
+}
+
 func (l *LinkedInClient) HandleMatrixEdit(ctx context.Context, msg *bridgev2.MatrixEdit) error {
 	if !l.IsLoggedIn() {
 		return ErrNotLoggedIn
+	} else if err := l.checkEditable(ctx, msg); err != nil {
+		return err
+	}
+	l.formatEmote(msg.Content, msg.OrigSender)
+	bodyContent := msg.Content
+	if msg.Content.MsgType == event.MsgBeeperGallery {
+		bodyContent = galleryCaption(msg.Content)
+	}
+	// The caption of a batched image is stored in the first part of the
+	// message along with the other parts of the split message.
+	firstPart := msg.EditTarget
+	if firstPart.PartID != "" {
+		var err error
+		firstPart, err = l.main.Bridge.DB.Message.GetFirstPartByID(ctx, msg.Portal.Receiver, msg.EditTarget.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get first part of edit target: %w", err)
+		} else if firstPart == nil {
+			firstPart = msg.EditTarget
+		}
+	}
+
+	chunks := matrixfmt.Split(matrixfmt.Parse(ctx, l.matrixParser, bodyContent), MaxTextLength)
+	ids := splitMessageIDs(firstPart)
+	if len(chunks) > len(ids) {
+		return ErrEditTooLong
 	}
-	return l.client.EditMessage(ctx, linkedingo.NewURN(msg.EditTarget.ID), matrixfmt.Parse(ctx, l.matrixParser, msg.Content))
+	for i, msgID := range ids {
+		if i < len(chunks) {
+			if err := l.client.EditMessage(ctx, l.messageURN(msgID), chunks[i]); err != nil {
+				return err
+			}
+		} else if err := l.client.RecallMessage(ctx, l.messageURN(msgID)); err != nil {
+			return err
+		}
+	}
+	if meta, ok := firstPart.Metadata.(*MessageMetadata); ok && len(ids) > len(chunks) {
+		// The edit is shorter than the original, so the remaining parts were
+		// recalled.
+		meta.SplitParts = ids[1:len(chunks)]
+		if firstPart != msg.EditTarget {
+			if err := l.main.Bridge.DB.Message.Update(ctx, firstPart); err != nil {
+				zerolog.Ctx(ctx).Err(err).Msg("Failed to save first part of edited message")
+			}
+		}
+	}
+	return nil
 }
 
 func (l *LinkedInClient) HandleMatrixMessageRemove(ctx context.Context, msg *bridgev2.MatrixMessageRemove) error {
 	if !l.IsLoggedIn() {
 		return ErrNotLoggedIn
+	} else if isNotificationsPortal(msg.Portal) {
+		return nil
 	}
-	return l.client.RecallMessage(ctx, linkedingo.NewURN(msg.TargetMessage.ID))
+	for _, msgID := range splitMessageIDs(msg.TargetMessage) {
+		if err := l.client.RecallMessage(ctx, l.messageURN(msgID)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (l *LinkedInClient) PreHandleMatrixReaction(ctx context.Context, msg *bridgev2.MatrixReaction) (bridgev2.MatrixReactionPreResponse, error) {
@@ -255,46 +350,90 @@ func (l *LinkedInClient) PreHandleMatrixReaction(ctx context.Context, msg *bridg
 func (l *LinkedInClient) HandleMatrixReaction(ctx context.Context, msg *bridgev2.MatrixReaction) (reaction *database.Reaction, err error) {
 	if !l.IsLoggedIn() {
 		return nil, ErrNotLoggedIn
+	} else if isNotificationsPortal(msg.Portal) {
+		return nil, ErrNotificationsRoomReadOnly
 	}
-	return &database.Reaction{}, l.client.SendReaction(ctx, linkedingo.NewURN(msg.TargetMessage.ID), msg.PreHandleResp.Emoji)
+	return &database.Reaction{}, l.client.SendReaction(ctx, l.messageURN(msg.TargetMessage.ID), msg.PreHandleResp.Emoji)
 }
 
 func (l *LinkedInClient) HandleMatrixReactionRemove(ctx context.Context, msg *bridgev2.MatrixReactionRemove) error {
-	return l.client.RemoveReaction(ctx, linkedingo.NewURN(msg.TargetReaction.MessageID), msg.TargetReaction.Emoji)
+	if isNotificationsPortal(msg.Portal) {
+		return nil
+	}
+	return l.client.RemoveReaction(ctx, l.messageURN(msg.TargetReaction.MessageID), msg.TargetReaction.Emoji)
 }
 
 func (l *LinkedInClient) HandleMatrixReadReceipt(ctx context.Context, msg *bridgev2.MatrixReadReceipt) error {
 	if !l.IsLoggedIn() {
 		return ErrNotLoggedIn
+	} else if isNotificationsPortal(msg.Portal) {
+		return nil
 	}
-	_, err := l.client.MarkConversationRead(ctx, linkedingo.NewURN(msg.Portal.ID))
+	_, err := l.client.MarkConversationRead(ctx, l.conversationURN(msg.Portal.ID))
 	return err
 }
 
 func (l *LinkedInClient) HandleMatrixTyping(ctx context.Context, msg *bridgev2.MatrixTyping) error {
 	if !l.IsLoggedIn() {
 		return ErrNotLoggedIn
+	} else if isNotificationsPortal(msg.Portal) {
+		return nil
 	}
 	if msg.IsTyping && msg.Type == bridgev2.TypingTypeText {
-		return l.client.StartTyping(ctx, linkedingo.NewURN(msg.Portal.ID))
+		return l.startLocalTyping(ctx, msg.Portal)
 	}
+	l.stopLocalTyping(msg.Portal.PortalKey)
 	return nil
 }
 
 func (l *LinkedInClient) HandleMatrixDeleteChat(ctx context.Context, chat *bridgev2.MatrixDeleteChat) error {
-	return l.client.DeleteConversation(ctx, linkedingo.NewURN(chat.Portal.ID))
+	if isNotificationsPortal(chat.Portal) {
+		return nil
+	}
+	return l.client.DeleteConversation(ctx, l.conversationURN(chat.Portal.ID))
 }
 
 func (l *LinkedInClient) HandleMatrixRoomName(ctx context.Context, msg *bridgev2.MatrixRoomName) (bool, error) {
-	err := l.client.RenameConversation(ctx, linkedingo.NewURN(msg.Portal.ID), msg.Content.Name)
+	if isNotificationsPortal(msg.Portal) {
+		return false, ErrNotificationsRoomReadOnly
+	}
+	err := l.client.RenameConversation(ctx, l.conversationURN(msg.Portal.ID), msg.Content.Name)
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
+var ErrChangingAdminsNotSupported = bridgev2.WrapErrorInStatus(errors.New("changing group admins isn't supported")).
+	WithIsCertain(true).WithErrorAsMessage()
+
+func (l *LinkedInClient) HandleMatrixPowerLevels(ctx context.Context, msg *bridgev2.MatrixPowerLevelChange) (bool, error) {
+	for _, change := range msg.Users {
+		if (change.OrigLevel >= moderatorPL) != (change.NewLevel >= moderatorPL) {
+			// Group roles aren't bridged to LinkedIn, the next sync will
+			// restore the actual levels.
+			return false, ErrChangingAdminsNotSupported
+		}
+	}
+	return false, nil
+}
+
 func (l *LinkedInClient) HandleMatrixMembership(ctx context.Context, msg *bridgev2.MatrixMembershipChange) (*bridgev2.MatrixMembershipResult, error) {
-	if msg.Portal.RoomType == database.RoomTypeDM {
+	if isNotificationsPortal(msg.Portal) {
+		if msg.Type == bridgev2.Leave {
+			return nil, nil
+		}
+		return nil, ErrNotificationsRoomReadOnly
+	} else if msg.Type == bridgev2.Leave {
+		if msg.Portal.RoomType == database.RoomTypeDM {
+			// LinkedIn doesn't have a way to leave DMs.
+			return nil, nil
+		}
+		return nil, l.client.LeaveConversation(ctx, l.conversationURN(msg.Portal.ID))
+	} else if msg.Portal.RoomType == database.RoomTypeDM {
+		if ghost, ok := msg.Target.(*bridgev2.Ghost); ok && msg.Type == bridgev2.Invite && getPortalMetadata(msg.Portal).CreatedByInvite {
+			return nil, l.upgradeDMToGroup(ctx, msg.Portal, ghost)
+		}
 		return nil, errors.New("cannot change members for DM")
 	}
 
@@ -309,9 +448,9 @@ func (l *LinkedInClient) HandleMatrixMembership(ctx context.Context, msg *bridge
 	var err error
 	switch msg.Type {
 	case bridgev2.Invite:
-		err = l.client.AddParticipants(ctx, linkedingo.NewURN(msg.Portal.ID), participants)
+		err = l.client.AddParticipants(ctx, l.conversationURN(msg.Portal.ID), participants)
 	case bridgev2.Kick:
-		err = l.client.RemoveParticipants(ctx, linkedingo.NewURN(msg.Portal.ID), participants)
+		err = l.client.RemoveParticipants(ctx, l.conversationURN(msg.Portal.ID), participants)
 	}
 
 	return nil, err
@@ -18,13 +18,16 @@ package connector
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"strings"
 
 	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
 	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/id"
 
 	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
 )
@@ -33,18 +36,141 @@ func (l *LinkedInClient) makePortalKey(conv linkedingo.Conversation) (key networ
 	key.ID = networkid.PortalID(conv.EntityURN.String())
 	if !conv.GroupChat || l.main.Bridge.Config.SplitPortals {
 		key.Receiver = l.userLogin.ID
+	} else if l.main.sharedGroupPortals() {
+		key.ID = networkid.PortalID(conv.EntityURN.WithID(mailboxlessID(conv.EntityURN)).String())
 	}
 	return key
 }
 
-func (l *LinkedInClient) makeSender(participant linkedingo.MessagingParticipant) (sender bridgev2.EventSender) {
-	id := participant.EntityURN.ID()
+// sharedGroupPortals returns true if group chats are shared between all
+// logins in the chat. Conversation and message URNs contain the mailbox of
+// the user who is viewing them, so shared portals and their messages use IDs
+// without the mailbox instead.
+func (lc *LinkedInConnector) sharedGroupPortals() bool {
+	return lc.Config.SharedGroupPortals && !lc.Bridge.Config.SplitPortals
+}
+
+// isSharedPortal returns true if the portal is a group chat which is shared
+// between all logins in it.
+func (lc *LinkedInConnector) isSharedPortal(portalKey networkid.PortalKey) bool {
+	return lc.sharedGroupPortals() && portalKey.Receiver == ""
+}
+
+// mailboxlessID returns the part of a conversation or message URN which is
+// the same for all participants, e.g. 2-abc== for
+// urn:li:msg_message:(urn:li:fsd_profile:ACoAA...,2-abc==).
+func mailboxlessID(urn linkedingo.URN) string {
+	id := urn.ID()
+	if !strings.HasPrefix(id, "(") || !strings.HasSuffix(id, ")") {
+		return id
+	}
+	_, after, found := strings.Cut(id[1:len(id)-1], ",")
+	if !found {
+		return id
+	}
+	return after
+}
+
+// withMailbox is the inverse of [mailboxlessID], it returns the URN as seen
+// by the current user.
+func (l *LinkedInClient) withMailbox(urn linkedingo.URN) linkedingo.URN {
+	if strings.HasPrefix(urn.ID(), "(") {
+		return urn
+	}
+	return urn.WithID(fmt.Sprintf("(urn:li:fsd_profile:%s,%s)", l.userID, urn.ID()))
+}
+
+// conversationURN returns the URN of the conversation of the portal as seen
+// by the current user.
+func (l *LinkedInClient) conversationURN(portalID networkid.PortalID) linkedingo.URN {
+	return l.withMailbox(linkedingo.NewURN(portalID))
+}
+
+// messageID returns the message ID of a message URN in the given portal.
+func (l *LinkedInClient) messageID(portalKey networkid.PortalKey, messageURN linkedingo.URN) networkid.MessageID {
+	if l.main.isSharedPortal(portalKey) {
+		return sharedMessageID(messageURN)
+	}
+	return networkid.MessageID(messageURN.String())
+}
+
+// sharedMessageID returns the ID of a message in a shared group portal.
+func sharedMessageID(messageURN linkedingo.URN) networkid.MessageID {
+	return networkid.MessageID(messageURN.WithID(mailboxlessID(messageURN)).String())
+}
+
+// getMessagePartByURN finds a bridged message when the portal it's in isn't
+// known, using the ID of the message both in shared group portals and in
+// other portals.
+func (l *LinkedInClient) getMessagePartByURN(
+	ctx context.Context,
+	messageURN linkedingo.URN,
+	getPart func(ctx context.Context, receiver networkid.UserLoginID, id networkid.MessageID) (*database.Message, error),
+) (*database.Message, error) {
+	if l.main.sharedGroupPortals() {
+		part, err := getPart(ctx, l.userLogin.ID, sharedMessageID(messageURN))
+		if err != nil || part != nil {
+			return part, err
+		}
+	}
+	return getPart(ctx, l.userLogin.ID, networkid.MessageID(messageURN.String()))
+}
+
+// messageURN returns the URN of a message as seen by the current user.
+func (l *LinkedInClient) messageURN(messageID networkid.MessageID) linkedingo.URN {
+	return l.withMailbox(linkedingo.NewURN(messageID))
+}
+
+func (l *LinkedInClient) makeSender(participant linkedingo.MessagingParticipant) bridgev2.EventSender {
+	return l.makeSenderByID(participant.EntityURN.ID())
+}
+
+// makeSenderByID returns the sender for a LinkedIn profile ID, which is
+// attributed to the login of that profile if it is logged into the bridge.
+func (l *LinkedInClient) makeSenderByID(id string) (sender bridgev2.EventSender) {
 	sender.IsFromMe = id == string(l.userID)
 	sender.Sender = networkid.UserID(id)
-	sender.SenderLogin = networkid.UserLoginID(id)
+	if sender.IsFromMe {
+		sender.SenderLogin = l.userLogin.ID
+	} else if login := l.main.Bridge.GetCachedUserLoginByID(MakeUserLoginID(id)); login != nil {
+		sender.SenderLogin = login.ID
+	}
 	return
 }
 
+// getMXIDByURN returns the Matrix user ID of a LinkedIn user. Users who are
+// logged into the bridge are resolved to their real Matrix account, everyone
+// else to their ghost.
+func (lc *LinkedInConnector) getMXIDByURN(ctx context.Context, entityURN linkedingo.URN) (id.UserID, error) {
+	login, err := lc.Bridge.GetExistingUserLoginByID(ctx, MakeUserLoginID(entityURN.ID()))
+	if err != nil {
+		return "", err
+	} else if login != nil {
+		return login.UserMXID, nil
+	}
+	ghost, err := lc.Bridge.GetGhostByID(ctx, networkid.UserID(entityURN.ID()))
+	if err != nil {
+		return "", err
+	}
+	return ghost.Intent.GetMXID(), nil
+}
+
+// getLoginByMXID returns the login of a real Matrix user which should be used
+// when they are mentioned. The current login is preferred if it belongs to the
+// user, so that multiple logins of the same user resolve consistently.
+func (l *LinkedInClient) getLoginByMXID(ctx context.Context, userID id.UserID) *bridgev2.UserLogin {
+	if userID == l.userLogin.UserMXID {
+		return l.userLogin
+	} else if _, isGhost := l.main.Bridge.Matrix.ParseGhostMXID(userID); isGhost {
+		return nil
+	}
+	user, err := l.main.Bridge.GetExistingUserByMXID(ctx, userID)
+	if err != nil || user == nil {
+		return nil
+	}
+	return user.GetDefaultLogin()
+}
+
 func MakeUserLoginID(userID string) networkid.UserLoginID {
 	return networkid.UserLoginID(userID)
 }
@@ -81,24 +207,18 @@ func readBytes(buf *bytes.Reader) (string, error) {
 	return string(bs), nil
 }
 
-func parseMessageID(messageID networkid.MessageID) (string, string) {
-	urn := linkedingo.NewURN(messageID)
-	idLen := len(urn.ID())
-	parts := strings.Split(urn.ID()[1:idLen-1], ",")
-	profileID, msgID := parts[0], parts[1]
-	return linkedingo.NewURN(profileID).ID(), msgID
-}
-
 func makeMessageID(senderID string, msgID string) networkid.MessageID {
 	id := fmt.Sprintf("urn:li:msg_message:(urn:li:fsd_profile:%s,%s)", senderID, msgID)
 	return networkid.MessageID(id)
 }
 
+// MakeMediaID returns the ID of a media part of a message. Version 1 IDs only
+// contained the part of the message ID after the mailbox, which can't be used
+// to find messages with mailboxless IDs, so version 2 contains the full ID.
 func MakeMediaID(userID networkid.UserLoginID, messageID networkid.MessageID, partID networkid.PartID) networkid.MediaID {
-	mediaID := []byte{1}
-	_, msgID := parseMessageID(messageID)
+	mediaID := []byte{2}
 	mediaID = appendBytes(mediaID, []byte(userID))
-	mediaID = appendBytes(mediaID, []byte(msgID))
+	mediaID = appendBytes(mediaID, []byte(messageID))
 	mediaID = appendBytes(mediaID, []byte(partID))
 	return mediaID
 }
@@ -110,7 +230,7 @@ func ParseMediaID(mediaID networkid.MediaID) (*MediaInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	if version[0] != byte(1) {
+	if version[0] != byte(1) && version[0] != byte(2) {
 		return nil, fmt.Errorf("unknown mediaID version: %v", version)
 	}
 
@@ -125,7 +245,11 @@ func ParseMediaID(mediaID networkid.MediaID) (*MediaInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	mediaInfo.MessageID = makeMessageID(userID, msgID)
+	if version[0] == byte(1) {
+		mediaInfo.MessageID = makeMessageID(userID, msgID)
+	} else {
+		mediaInfo.MessageID = networkid.MessageID(msgID)
+	}
 
 	str, err := readBytes(buf)
 	if err != nil {
@@ -0,0 +1,66 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector"
+)
+
+func TestMediaIDRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		messageID networkid.MessageID
+		partID    networkid.PartID
+	}{
+		{"mailbox", "urn:li:msg_message:(urn:li:fsd_profile:ACoAA,2-abc==)", ""},
+		{"shared group portals", "urn:li:msg_message:2-abc==", "part_1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mediaID := connector.MakeMediaID("ACoAA", test.messageID, test.partID)
+			info, err := connector.ParseMediaID(mediaID)
+			require.NoError(t, err)
+			assert.Equal(t, &connector.MediaInfo{
+				UserID:    "ACoAA",
+				MessageID: test.messageID,
+				PartID:    test.partID,
+			}, info)
+		})
+	}
+}
+
+func TestParseMediaIDVersion1(t *testing.T) {
+	mediaID := []byte{1}
+	for _, field := range []string{"ACoAA", "2-abc==", "part_0"} {
+		mediaID = binary.AppendUvarint(mediaID, uint64(len(field)))
+		mediaID = append(mediaID, field...)
+	}
+	info, err := connector.ParseMediaID(mediaID)
+	require.NoError(t, err)
+	assert.Equal(t, &connector.MediaInfo{
+		UserID:    "ACoAA",
+		MessageID: "urn:li:msg_message:(urn:li:fsd_profile:ACoAA,2-abc==)",
+		PartID:    "part_0",
+	}, info)
+}
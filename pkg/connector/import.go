@@ -0,0 +1,285 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/linkedinexport"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// importBatchSize is the number of imported messages sent per backfill batch.
+const importBatchSize = 100
+
+// importParticipantLookupCount is the number of recent messages fetched from
+// LinkedIn to map export senders to profile IDs.
+const importParticipantLookupCount = 40
+
+type ImportResult struct {
+	Threads           int
+	Messages          int
+	AlreadyBridged    int
+	MissingPortals    int
+	UnresolvedSenders int
+}
+
+// exportSenderResolver maps the senders in an export, which are only
+// identified by name and profile URL, to LinkedIn user IDs.
+type exportSenderResolver struct {
+	selfSlugs map[string]struct{}
+	bySlug    map[string]networkid.UserID
+	byName    map[string]networkid.UserID
+}
+
+func (r *exportSenderResolver) add(participant linkedingo.MessagingParticipant) {
+	if participant.ParticipantType.Member == nil {
+		return
+	}
+	userID := networkid.UserID(participant.EntityURN.ID())
+	member := participant.ParticipantType.Member
+	if slug := linkedinexport.ProfileSlug(member.ProfileURL); slug != "" {
+		r.bySlug[slug] = userID
+	}
+	name := strings.TrimSpace(member.FirstName.Text + " " + member.LastName.Text)
+	if name != "" {
+		r.byName[strings.ToLower(name)] = userID
+	}
+}
+
+func (r *exportSenderResolver) resolve(l *LinkedInClient, msg linkedinexport.Message) (sender bridgev2.EventSender, ok bool) {
+	slug := linkedinexport.ProfileSlug(msg.SenderProfileURL)
+	if _, isSelf := r.selfSlugs[slug]; isSelf {
+		return bridgev2.EventSender{IsFromMe: true, Sender: l.userID, SenderLogin: l.userLogin.ID}, true
+	}
+	userID, ok := r.bySlug[slug]
+	if !ok {
+		userID, ok = r.byName[strings.ToLower(msg.From)]
+	}
+	if !ok && strings.HasPrefix(slug, "ACoA") {
+		// Profiles without a vanity name use the profile ID in the URL.
+		userID, ok = networkid.UserID(slug), true
+	}
+	if !ok {
+		return
+	}
+	return l.makeSenderByID(string(userID)), true
+}
+
+// bridgedMessageKey identifies a message by its sender and the second it was
+// sent in, which is the precision of the timestamps in the export.
+type bridgedMessageKey struct {
+	sender networkid.UserID
+	second int64
+}
+
+// getBridgedMessageCounts counts the messages which are already bridged in
+// the portal between start and end (inclusive) by sender and second. Exported
+// messages don't have LinkedIn message IDs, and the bridge database doesn't
+// store message text, so this is used to match exported messages to bridged
+// ones.
+func (l *LinkedInClient) getBridgedMessageCounts(ctx context.Context, portal *bridgev2.Portal, start, end time.Time) (map[bridgedMessageKey]int, error) {
+	messages, err := l.main.Bridge.DB.Message.GetMessagesBetweenTimeQuery(ctx, portal.PortalKey, start.Add(-time.Nanosecond), end.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	counts := map[bridgedMessageKey]int{}
+	seen := map[networkid.MessageID]struct{}{}
+	for _, msg := range messages {
+		if _, ok := seen[msg.ID]; ok {
+			// Only count each message once, not every part of it.
+			continue
+		}
+		seen[msg.ID] = struct{}{}
+		counts[bridgedMessageKey{sender: msg.SenderID, second: msg.Timestamp.Unix()}]++
+	}
+	return counts, nil
+}
+
+func (l *LinkedInClient) ImportExport(ctx context.Context, threads []*linkedinexport.Thread) (*ImportResult, error) {
+	log := zerolog.Ctx(ctx).With().Str("action", "import_export").Logger()
+	ctx = log.WithContext(ctx)
+
+	selfSlugs := map[string]struct{}{string(l.userID): {}}
+	profile, err := l.client.GetCurrentUserProfile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get own profile: %w", err)
+	} else if profile.MiniProfile.PublicIdentifier != "" {
+		selfSlugs[profile.MiniProfile.PublicIdentifier] = struct{}{}
+	}
+
+	var result ImportResult
+	for _, thread := range threads {
+		portal, err := l.getImportPortal(ctx, thread.ConversationID)
+		if err != nil {
+			return nil, err
+		} else if portal == nil || portal.MXID == "" {
+			log.Debug().Str("conversation_id", thread.ConversationID).Msg("No portal for exported conversation")
+			result.MissingPortals++
+			continue
+		}
+		count, bridged, unresolved, err := l.importThread(ctx, portal, thread, selfSlugs)
+		if err != nil {
+			return nil, err
+		}
+		result.AlreadyBridged += bridged
+		if count > 0 {
+			result.Threads++
+			result.Messages += count
+		}
+		result.UnresolvedSenders += unresolved
+	}
+	return &result, nil
+}
+
+func (l *LinkedInClient) getImportPortal(ctx context.Context, conversationID string) (*bridgev2.Portal, error) {
+	portalID := networkid.PortalID(fmt.Sprintf("urn:li:msg_conversation:(urn:li:fsd_profile:%s,%s)", l.userID, conversationID))
+	portal, err := l.main.Bridge.GetExistingPortalByKey(ctx, networkid.PortalKey{ID: portalID, Receiver: l.userLogin.ID})
+	if err != nil || portal != nil {
+		return portal, err
+	}
+	portal, err = l.main.Bridge.GetExistingPortalByKey(ctx, networkid.PortalKey{ID: portalID})
+	if err != nil || portal != nil || !l.main.sharedGroupPortals() {
+		return portal, err
+	}
+	return l.main.Bridge.GetExistingPortalByKey(ctx, networkid.PortalKey{ID: networkid.PortalID("urn:li:msg_conversation:" + conversationID)})
+}
+
+func (l *LinkedInClient) importThread(ctx context.Context, portal *bridgev2.Portal, thread *linkedinexport.Thread, selfSlugs map[string]struct{}) (count, bridged, unresolved int, err error) {
+	log := zerolog.Ctx(ctx).With().Str("portal_id", string(portal.ID)).Logger()
+	resolver := &exportSenderResolver{
+		selfSlugs: selfSlugs,
+		bySlug:    map[string]networkid.UserID{},
+		byName:    map[string]networkid.UserID{},
+	}
+	recent, err := l.client.GetMessagesBefore(ctx, l.conversationURN(portal.ID), time.Now(), importParticipantLookupCount)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fetch recent messages to resolve export senders")
+	} else {
+		for _, msg := range recent.Elements {
+			resolver.add(msg.Sender)
+			for _, participant := range msg.Conversation.ConversationParticipants {
+				resolver.add(participant)
+			}
+		}
+	}
+
+	bridgedCounts, err := l.getBridgedMessageCounts(ctx, portal, thread.Messages[0].Date, thread.Messages[len(thread.Messages)-1].Date)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to get bridged messages: %w", err)
+	}
+
+	messages := make([]*bridgev2.BackfillMessage, 0, len(thread.Messages))
+	for _, msg := range thread.Messages {
+		sender, ok := resolver.resolve(l, msg)
+		if !ok {
+			log.Debug().
+				Str("from", msg.From).
+				Str("sender_profile_url", msg.SenderProfileURL).
+				Msg("Couldn't resolve sender of exported message")
+			unresolved++
+			continue
+		}
+		key := bridgedMessageKey{sender: sender.Sender, second: msg.Date.Unix()}
+		if bridgedCounts[key] > 0 {
+			bridgedCounts[key]--
+			bridged++
+			continue
+		}
+		messages = append(messages, &bridgev2.BackfillMessage{
+			ConvertedMessage: &bridgev2.ConvertedMessage{
+				Parts: []*bridgev2.ConvertedMessagePart{{
+					Type: event.EventMessage,
+					Content: &event.MessageEventContent{
+						MsgType: event.MsgText,
+						Body:    msg.Body(),
+					},
+				}},
+			},
+			Sender:      sender,
+			ID:          makeMessageID(string(sender.Sender), "export-"+msg.ID()),
+			Timestamp:   msg.Date,
+			StreamOrder: msg.Date.UnixMilli(),
+		})
+	}
+	if len(messages) == 0 {
+		return 0, bridged, unresolved, nil
+	}
+
+	err = l.main.Bridge.DB.BackfillTask.EnsureExists(ctx, portal.PortalKey, l.userLogin.ID)
+	if err != nil {
+		return 0, bridged, unresolved, fmt.Errorf("failed to ensure backfill task exists: %w", err)
+	}
+	task, err := l.main.Bridge.DB.BackfillTask.GetNextForPortal(ctx, portal.PortalKey, true)
+	if err != nil {
+		return 0, bridged, unresolved, fmt.Errorf("failed to get backfill task: %w", err)
+	}
+	var cursor networkid.PaginationCursor
+	if task != nil {
+		cursor = task.Cursor
+	}
+	l.queueImportBatches(ctx, portal, messages, cursor)
+	return len(messages), bridged, unresolved, nil
+}
+
+// queueImportBatches sends the messages through the backwards backfill queue,
+// newest batch first. The bridge cuts off anything newer than the oldest
+// bridged message and drops messages whose IDs are already in the database.
+// Each batch is queued after the previous one is done, from a new goroutine,
+// as the done callback is called from the backfill queue, which would block
+// if the queue is full.
+func (l *LinkedInClient) queueImportBatches(ctx context.Context, portal *bridgev2.Portal, messages []*bridgev2.BackfillMessage, cursor networkid.PaginationCursor) {
+	log := zerolog.Ctx(ctx)
+	end := len(messages)
+	var next func(err error)
+	next = func(err error) {
+		if err != nil {
+			log.Err(err).Str("portal_id", string(portal.ID)).Msg("Failed to import exported messages")
+			return
+		} else if end <= 0 {
+			return
+		}
+		start := max(0, end-importBatchSize)
+		batch := messages[start:end]
+		end = start
+		l.main.Bridge.WakeupBackfillQueue(&bridgev2.ManualBackfill{
+			Source: l.userLogin,
+			Portal: portal,
+			Data: &bridgev2.FetchMessagesResponse{
+				Messages: batch,
+				Cursor:   cursor,
+				// The export contains the full history, so there's nothing
+				// left to fetch after the oldest batch.
+				HasMore:                 start > 0,
+				MarkRead:                true,
+				AggressiveDeduplication: true,
+			},
+			DoneCallback: func(err error) {
+				go next(err)
+			},
+		})
+	}
+	next(nil)
+}
@@ -0,0 +1,101 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/format"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// invitationPageSize is the number of received invitations that are fetched
+// per request.
+const invitationPageSize = 100
+
+func formatInvitation(view linkedingo.InvitationView, withCommands bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**", view.Title.GetText())
+	if headline := view.Subtitle.GetText(); headline != "" {
+		fmt.Fprintf(&b, " (%s)", headline)
+	}
+	if mutual := view.MutualConnections(); mutual != "" {
+		fmt.Fprintf(&b, "  \n%s", mutual)
+	}
+	if view.Invitation.Message != "" {
+		fmt.Fprintf(&b, "  \n> %s", strings.ReplaceAll(view.Invitation.Message, "\n", "\n> "))
+	}
+	id := view.Invitation.EntityURN.ID()
+	if withCommands {
+		fmt.Fprintf(&b, "\n\nUse `accept-invitation %s` to accept or `ignore-invitation %s` to ignore.", id, id)
+	} else {
+		fmt.Fprintf(&b, "  \nID: `%s`", id)
+	}
+	return b.String()
+}
+
+// findReceivedInvitation looks up a pending invitation by the ID part of its
+// URN, paging through all received invitations.
+func (l *LinkedInClient) findReceivedInvitation(ctx context.Context, invitationID string) (*linkedingo.InvitationView, error) {
+	for start := 0; ; {
+		resp, err := l.client.GetReceivedInvitations(ctx, start, invitationPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, view := range resp.Elements {
+			if view.Invitation.EntityURN.ID() == invitationID {
+				return &view, nil
+			}
+		}
+		start += len(resp.Elements)
+		if len(resp.Elements) == 0 || (resp.Paging.Total > 0 && start >= resp.Paging.Total) {
+			return nil, nil
+		}
+	}
+}
+
+func (l *LinkedInClient) onRealtimeInvitation(ctx context.Context, _ *linkedingo.DecoratedEvent, evt *linkedingo.RealtimeInvitationEvent) {
+	if evt == nil {
+		return
+	}
+	log := zerolog.Ctx(ctx).With().Stringer("invitation_urn", evt.EntityURN).Logger()
+	view, err := l.findReceivedInvitation(ctx, evt.EntityURN.ID())
+	if err != nil {
+		log.Err(err).Msg("Failed to fetch received invitations")
+		return
+	} else if view == nil {
+		log.Debug().Msg("Invitation is not pending, ignoring")
+		return
+	}
+
+	roomID, err := l.userLogin.User.GetManagementRoom(ctx)
+	if err != nil {
+		log.Err(err).Msg("Failed to get management room")
+		return
+	}
+	content := format.RenderMarkdown("New connection request from "+formatInvitation(*view, true), true, false)
+	content.MsgType = event.MsgNotice
+	_, err = l.main.Bridge.Bot.SendMessage(ctx, roomID, event.EventMessage, &event.Content{Parsed: &content}, nil)
+	if err != nil {
+		log.Err(err).Msg("Failed to send connection request notice")
+	}
+}
@@ -0,0 +1,241 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package linkedinexport reads the messages.csv file from LinkedIn's
+// "Download your data" archive.
+package linkedinexport
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const MessagesFileName = "messages.csv"
+
+var ErrNoMessagesFile = errors.New("archive doesn't contain " + MessagesFileName)
+
+// Column names in messages.csv.
+const (
+	columnConversationID       = "CONVERSATION ID"
+	columnConversationTitle    = "CONVERSATION TITLE"
+	columnFrom                 = "FROM"
+	columnSenderProfileURL     = "SENDER PROFILE URL"
+	columnTo                   = "TO"
+	columnRecipientProfileURLs = "RECIPIENT PROFILE URLS"
+	columnDate                 = "DATE"
+	columnSubject              = "SUBJECT"
+	columnContent              = "CONTENT"
+	columnFolder               = "FOLDER"
+	columnIsDraft              = "IS MESSAGE DRAFT"
+)
+
+var requiredColumns = []string{columnConversationID, columnFrom, columnSenderProfileURL, columnDate, columnContent}
+
+var dateLayouts = []string{
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+}
+
+// Message is a single row of messages.csv.
+type Message struct {
+	ConversationID       string
+	ConversationTitle    string
+	From                 string
+	SenderProfileURL     string
+	To                   string
+	RecipientProfileURLs []string
+	Date                 time.Time
+	Subject              string
+	Content              string
+	Folder               string
+}
+
+// ID returns a stable identifier for the message. The export doesn't include
+// message IDs, so the ID is derived from the conversation, sender, timestamp
+// and content, which makes repeated imports of the same archive idempotent.
+func (m *Message) ID() string {
+	hash := sha256.New()
+	for _, part := range []string{m.ConversationID, m.SenderProfileURL, m.Date.UTC().Format(time.RFC3339), m.Subject, m.Content} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// Body returns the text of the message, prefixed with the subject if there
+// is one.
+func (m *Message) Body() string {
+	if m.Subject == "" {
+		return m.Content
+	}
+	return m.Subject + "\n\n" + m.Content
+}
+
+// Thread is the history of one conversation, in chronological order.
+type Thread struct {
+	ConversationID string
+	Title          string
+	Messages       []Message
+}
+
+// ProfileSlug returns the last path segment of a profile URL such as
+// https://www.linkedin.com/in/john-doe, which is either the vanity name or
+// the profile ID.
+func ProfileSlug(profileURL string) string {
+	parsed, err := url.Parse(profileURL)
+	if err != nil {
+		return ""
+	}
+	profilePath := strings.Trim(parsed.Path, "/")
+	if profilePath == "" {
+		return ""
+	}
+	return path.Base(profilePath)
+}
+
+// ReadZip reads messages.csv from an export archive.
+func ReadZip(r io.ReaderAt, size int64) ([]Message, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	for _, file := range archive.File {
+		if !strings.EqualFold(path.Base(file.Name), MessagesFileName) {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		defer f.Close()
+		return ReadCSV(f)
+	}
+	return nil, ErrNoMessagesFile
+}
+
+// ReadCSV parses the contents of messages.csv. Drafts are skipped.
+func ReadCSV(r io.Reader) ([]Message, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	var messages []Message
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		get := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		if strings.EqualFold(get(columnIsDraft), "yes") || strings.EqualFold(get(columnFolder), "draft") {
+			continue
+		}
+		date, err := parseDate(get(columnDate))
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		msg := Message{
+			ConversationID:    get(columnConversationID),
+			ConversationTitle: get(columnConversationTitle),
+			From:              get(columnFrom),
+			SenderProfileURL:  get(columnSenderProfileURL),
+			To:                get(columnTo),
+			Date:              date,
+			Subject:           get(columnSubject),
+			Content:           get(columnContent),
+			Folder:            get(columnFolder),
+		}
+		for _, recipient := range strings.Split(get(columnRecipientProfileURLs), ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				msg.RecipientProfileURLs = append(msg.RecipientProfileURLs, recipient)
+			}
+		}
+		if msg.ConversationID == "" {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// GroupByConversation splits the messages into threads. Threads are ordered by
+// their first message, and the messages in each thread are in chronological
+// order.
+func GroupByConversation(messages []Message) []*Thread {
+	threadMap := map[string]*Thread{}
+	var threads []*Thread
+	for _, msg := range messages {
+		thread, ok := threadMap[msg.ConversationID]
+		if !ok {
+			thread = &Thread{ConversationID: msg.ConversationID}
+			threadMap[msg.ConversationID] = thread
+			threads = append(threads, thread)
+		}
+		if thread.Title == "" {
+			thread.Title = msg.ConversationTitle
+		}
+		thread.Messages = append(thread.Messages, msg)
+	}
+	for _, thread := range threads {
+		slices.SortStableFunc(thread.Messages, func(a, b Message) int {
+			return a.Date.Compare(b.Date)
+		})
+	}
+	slices.SortStableFunc(threads, func(a, b *Thread) int {
+		return a.Messages[0].Date.Compare(b.Messages[0].Date)
+	})
+	return threads
+}
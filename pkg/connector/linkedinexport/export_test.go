@@ -0,0 +1,104 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkedinexport_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/linkedinexport"
+)
+
+const messagesCSV = "\ufeffCONVERSATION ID,CONVERSATION TITLE,FROM,SENDER PROFILE URL,TO,RECIPIENT PROFILE URLS,DATE,SUBJECT,CONTENT,FOLDER,IS MESSAGE DRAFT\n" +
+	`2-abc,,Jane Doe,https://www.linkedin.com/in/jane-doe,John Smith,https://www.linkedin.com/in/john-smith,2021-03-04 10:00:00 UTC,,"Thanks, talk soon!",INBOX,No` + "\n" +
+	`2-abc,,John Smith,https://www.linkedin.com/in/john-smith,Jane Doe,https://www.linkedin.com/in/jane-doe,2021-03-04 09:30:00 UTC,Hello,"Hi Jane,` + "\n" + `nice to meet you",INBOX,No` + "\n" +
+	`2-def,Team chat,John Smith,https://www.linkedin.com/in/john-smith,"Jane Doe, Bob","https://www.linkedin.com/in/jane-doe,https://www.linkedin.com/in/bob",2020-01-01 00:00:00 UTC,,hi all,INBOX,No` + "\n" +
+	`2-def,Team chat,John Smith,https://www.linkedin.com/in/john-smith,,,2020-01-02 00:00:00 UTC,,unsent,DRAFT,Yes` + "\n"
+
+func TestReadCSV(t *testing.T) {
+	messages, err := linkedinexport.ReadCSV(strings.NewReader(messagesCSV))
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+
+	assert.Equal(t, "2-abc", messages[0].ConversationID)
+	assert.Equal(t, "Jane Doe", messages[0].From)
+	assert.Equal(t, "Thanks, talk soon!", messages[0].Content)
+	assert.Equal(t, time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC), messages[0].Date.UTC())
+	assert.Equal(t, "Hello\n\nHi Jane,\nnice to meet you", messages[1].Body())
+	assert.Equal(t, []string{"https://www.linkedin.com/in/jane-doe", "https://www.linkedin.com/in/bob"}, messages[2].RecipientProfileURLs)
+}
+
+func TestReadCSVMissingColumn(t *testing.T) {
+	_, err := linkedinexport.ReadCSV(strings.NewReader("CONVERSATION ID,FROM\n2-abc,Jane\n"))
+	assert.ErrorContains(t, err, "SENDER PROFILE URL")
+}
+
+func TestReadZip(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create("Basic_LinkedInDataExport_01-01-2025/messages.csv")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(messagesCSV))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	messages, err := linkedinexport.ReadZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Len(t, messages, 3)
+
+	var empty bytes.Buffer
+	require.NoError(t, zip.NewWriter(&empty).Close())
+	_, err = linkedinexport.ReadZip(bytes.NewReader(empty.Bytes()), int64(empty.Len()))
+	assert.ErrorIs(t, err, linkedinexport.ErrNoMessagesFile)
+}
+
+func TestGroupByConversation(t *testing.T) {
+	messages, err := linkedinexport.ReadCSV(strings.NewReader(messagesCSV))
+	require.NoError(t, err)
+
+	threads := linkedinexport.GroupByConversation(messages)
+	require.Len(t, threads, 2)
+	assert.Equal(t, "2-def", threads[0].ConversationID)
+	assert.Equal(t, "Team chat", threads[0].Title)
+	require.Len(t, threads[1].Messages, 2)
+	assert.Equal(t, "John Smith", threads[1].Messages[0].From)
+	assert.Equal(t, "Jane Doe", threads[1].Messages[1].From)
+}
+
+func TestMessageID(t *testing.T) {
+	messages, err := linkedinexport.ReadCSV(strings.NewReader(messagesCSV))
+	require.NoError(t, err)
+	again, err := linkedinexport.ReadCSV(strings.NewReader(messagesCSV))
+	require.NoError(t, err)
+
+	assert.Equal(t, messages[0].ID(), again[0].ID())
+	assert.NotEqual(t, messages[0].ID(), messages[1].ID())
+}
+
+func TestProfileSlug(t *testing.T) {
+	assert.Equal(t, "jane-doe", linkedinexport.ProfileSlug("https://www.linkedin.com/in/jane-doe"))
+	assert.Equal(t, "jane-doe", linkedinexport.ProfileSlug("https://www.linkedin.com/in/jane-doe/"))
+	assert.Equal(t, "ACoAAB", linkedinexport.ProfileSlug("https://www.linkedin.com/in/ACoAAB"))
+	assert.Equal(t, "", linkedinexport.ProfileSlug(""))
+	assert.Equal(t, "", linkedinexport.ProfileSlug("https://www.linkedin.com/"))
+}
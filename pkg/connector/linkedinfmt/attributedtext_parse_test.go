@@ -38,7 +38,7 @@ var attributedtextFS embed.FS
 
 var linkedinFmtParams = linkedinfmt.FormatParams{
 	GetMXIDByURN: func(ctx context.Context, entityURN linkedingo.URN) (id.UserID, error) {
-		return id.UserID(entityURN.ID()), nil
+		return id.NewUserID(strings.ToLower(entityURN.ID()), "example.com"), nil
 	},
 }
 
@@ -73,3 +73,115 @@ func TestParse(t *testing.T) {
 	}
 
 }
+
+func TestParseAttributes(t *testing.T) {
+	attr := func(start, length int, kind linkedingo.AttributeKind) linkedingo.Attribute {
+		return linkedingo.Attribute{Start: start, Length: length, AttributeKind: kind}
+	}
+	bold := linkedingo.AttributeKind{Bold: &linkedingo.Bold{}}
+	italic := linkedingo.AttributeKind{Italic: &linkedingo.Italic{}}
+	paragraph := linkedingo.AttributeKind{Paragraph: &linkedingo.Paragraph{}}
+	listItem := linkedingo.AttributeKind{ListItem: &linkedingo.ListItem{}}
+	tests := []struct {
+		name       string
+		text       string
+		attributes []linkedingo.Attribute
+		expected   string
+	}{
+		{
+			"escaping",
+			"<script> & \"quotes\"\nnext line 🚀 rocket",
+			[]linkedingo.Attribute{attr(9, 10, bold), attr(32, 6, italic)},
+			"&lt;script&gt; <strong>&amp; &#34;quotes&#34;</strong><br/>next line 🚀 <em>rocket</em>",
+		},
+		{
+			"overlapping styles",
+			"bold and italic text, H2O and E=mc2, underlined",
+			[]linkedingo.Attribute{
+				attr(0, 13, bold),
+				attr(5, 15, italic),
+				attr(23, 1, linkedingo.AttributeKind{Subscript: &linkedingo.Subscript{}}),
+				attr(34, 1, linkedingo.AttributeKind{Superscript: &linkedingo.Superscript{}}),
+				attr(37, 10, linkedingo.AttributeKind{Underline: &linkedingo.Underline{}}),
+			},
+			"<strong>bold <em>and ital</em></strong><em>ic text</em>, H<sub>2</sub>O and E=mc<sup>2</sup>, <u>underlined</u>",
+		},
+		{
+			"hyperlinks",
+			"Check out https://example.com/?a=1&b=2 and read our docs.",
+			[]linkedingo.Attribute{
+				attr(10, 28, linkedingo.AttributeKind{Hyperlink: &linkedingo.Hyperlink{URL: "https://example.com/?a=1&b=2"}}),
+				attr(48, 8, linkedingo.AttributeKind{Hyperlink: &linkedingo.Hyperlink{URL: "https://docs.example.com/\"quoted\""}}),
+			},
+			"Check out <a href=\"https://example.com/?a=1&amp;b=2\">https://example.com/?a=1&amp;b=2</a> and read <a href=\"https://docs.example.com/&#34;quoted&#34;\">our docs</a>.",
+		},
+		{
+			"unordered list",
+			"Agenda:\nIntros\nRoadmap\nQ&A",
+			[]linkedingo.Attribute{
+				attr(0, 7, paragraph),
+				attr(8, 18, linkedingo.AttributeKind{List: &linkedingo.List{}}),
+				attr(8, 6, listItem),
+				attr(15, 7, listItem),
+				attr(23, 3, listItem),
+			},
+			"<p>Agenda:</p><ul><li>Intros</li><li>Roadmap</li><li>Q&amp;A</li></ul>",
+		},
+		{
+			"nested list",
+			"Steps:\nInstall\nDownload it\nRun it\nConfigure\n",
+			[]linkedingo.Attribute{
+				attr(0, 7, paragraph),
+				attr(7, 37, linkedingo.AttributeKind{List: &linkedingo.List{Ordered: true}}),
+				attr(7, 27, listItem),
+				attr(15, 18, linkedingo.AttributeKind{List: &linkedingo.List{}}),
+				attr(15, 11, listItem),
+				attr(27, 6, listItem),
+				attr(34, 10, listItem),
+			},
+			"<p>Steps:</p><ol><li>Install<ul><li>Download it</li><li>Run it</li></ul></li><li>Configure</li></ol>",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, err := linkedinfmt.Parse(context.TODO(), test.text, test.attributes, linkedinFmtParams)
+			require.NoError(t, err)
+			assert.Equal(t, test.text, content.Body)
+			assert.Equal(t, test.expected, content.FormattedBody)
+		})
+	}
+
+	t.Run("mentions", func(t *testing.T) {
+		content, err := linkedinfmt.Parse(context.TODO(), "Hey @Alice Smith, meet @Bob!", []linkedingo.Attribute{
+			attr(4, 12, linkedingo.AttributeKind{Entity: &linkedingo.Entity{URN: linkedingo.NewURN("urn:li:fsd_profile:ACoAAAlice")}}),
+			attr(23, 4, linkedingo.AttributeKind{Entity: &linkedingo.Entity{URN: linkedingo.NewURN("urn:li:fsd_profile:ACoAABob")}}),
+		}, linkedinFmtParams)
+		require.NoError(t, err)
+		assert.Equal(t, "Hey <a href=\"https://matrix.to/#/@acoaaalice:example.com\">Alice Smith</a>, meet <a href=\"https://matrix.to/#/@acoaabob:example.com\">Bob</a>!", content.FormattedBody)
+		require.NotNil(t, content.Mentions)
+		assert.Equal(t, []id.UserID{"@acoaaalice:example.com", "@acoaabob:example.com"}, content.Mentions.UserIDs)
+	})
+}
+
+func TestParseEdgeCases(t *testing.T) {
+	bold := linkedingo.AttributeKind{Bold: &linkedingo.Bold{}}
+	tests := []struct {
+		name       string
+		text       string
+		attributes []linkedingo.Attribute
+		expected   string
+	}{
+		{"truncated", "hello", []linkedingo.Attribute{{Start: 3, Length: 10, AttributeKind: bold}}, "hel<strong>lo</strong>"},
+		{"out of bounds", "hello", []linkedingo.Attribute{{Start: 10, Length: 2, AttributeKind: bold}}, "hello"},
+		{"unknown kind", "hello", []linkedingo.Attribute{{Start: 0, Length: 5}}, "hello"},
+		{"empty link", "hello", []linkedingo.Attribute{{Start: 0, Length: 5, AttributeKind: linkedingo.AttributeKind{Hyperlink: &linkedingo.Hyperlink{}}}}, "hello"},
+		{"matrix.to link", "@user", []linkedingo.Attribute{{Start: 0, Length: 5, AttributeKind: linkedingo.AttributeKind{Hyperlink: &linkedingo.Hyperlink{URL: "https://matrix.to/#/@user:example.com"}}}}, "https://matrix.to/#/@user:example.com"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, err := linkedinfmt.Parse(context.TODO(), test.text, test.attributes, linkedinFmtParams)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, content.FormattedBody)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"html"
 	"slices"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"golang.org/x/exp/maps"
@@ -41,6 +42,14 @@ type FormatParams struct {
 
 type formatContext struct {
 	IsInCodeblock bool
+	// IsInList is set directly inside lists, where only list items can
+	// contain text.
+	IsInList bool
+	// TrimTrailingNewlines is set inside block elements, where a newline at
+	// the end would render as an extra empty line.
+	TrimTrailingNewlines bool
+	// TrimLeadingNewline is set for text right after a block element.
+	TrimLeadingNewline bool
 }
 
 func (ctx formatContext) TextToHTML(text string) string {
@@ -76,6 +85,10 @@ func Parse(ctx context.Context, message string, attributes []linkedingo.Attribut
 			Start:  a.Start,
 			Length: a.Length,
 		}.TruncateEnd(maxLength)
+		if br.Start < 0 || br.Length < 0 {
+			log.Warn().Int("start", a.Start).Int("length", a.Length).Msg("Attribute is out of bounds")
+			continue
+		}
 		switch {
 		case a.AttributeKind.Bold != nil:
 			br.Value = Style{Type: StyleBold}
@@ -89,7 +102,7 @@ func Parse(ctx context.Context, message string, attributes []linkedingo.Attribut
 					Msg("Failed to get user info for mention")
 				continue // Skip this mention
 			}
-			userInfo.Name = string(charArr[a.Start+1 : a.Start+a.Length])
+			userInfo.Name = strings.TrimPrefix(string(charArr[br.Start:br.End()]), "@")
 			mentions[userInfo.MXID] = struct{}{}
 			br.Value = Mention{userInfo, networkid.UserID(urn.ID())}
 		case a.AttributeKind.Hyperlink != nil:
@@ -112,11 +125,13 @@ func Parse(ctx context.Context, message string, attributes []linkedingo.Attribut
 			br.Value = Style{Type: StyleUnderline}
 		default:
 			log.Warn().Any("kind", a.AttributeKind).Msg("Unhandled attribute")
+			continue
 		}
 		lrt.Add(br)
 	}
 
 	content.Mentions.UserIDs = maps.Keys(mentions)
+	slices.Sort(content.Mentions.UserIDs)
 	content.FormattedBody = lrt.Format(charArr, formatContext{})
 	content.Format = event.FormatHTML
 	return content, nil
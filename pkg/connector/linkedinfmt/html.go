@@ -18,11 +18,12 @@ package linkedinfmt
 
 import (
 	"fmt"
+	"html"
 	"strings"
 )
 
 func (m Mention) Format(message string) string {
-	return fmt.Sprintf(`<a href="%s">%s</a>`, m.MXID.URI().MatrixToURL(), m.Name)
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(m.MXID.URI().MatrixToURL()), html.EscapeString(m.Name))
 }
 
 func (s Style) Format(message string) string {
@@ -48,10 +49,13 @@ func (s Style) Format(message string) string {
 	case StyleSuperscript:
 		return fmt.Sprintf("<sup>%s</sup>", message)
 	case StyleHyperlink:
-		if strings.HasPrefix(s.URL, "https://matrix.to/#") {
-			return s.URL
+		if s.URL == "" {
+			return message
+		} else if strings.HasPrefix(s.URL, "https://matrix.to/#") {
+			// Bare matrix.to links are rendered as pills by Matrix clients.
+			return html.EscapeString(s.URL)
 		}
-		return fmt.Sprintf(`<a href='%s'>%s</a>`, s.URL, message)
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(s.URL), message)
 	case StyleUnderline:
 		return fmt.Sprintf("<u>%s</u>", message)
 	default:
@@ -59,17 +63,61 @@ func (s Style) Format(message string) string {
 	}
 }
 
+func isBlock(value BodyRangeValue) bool {
+	style, ok := value.(Style)
+	return ok && (style.Type == StyleList || style.Type == StyleListItem || style.Type == StyleParagraph)
+}
+
+// childContext returns the context for formatting the text inside the given
+// node.
+func (ctx formatContext) childContext(value BodyRangeValue) formatContext {
+	ctx.TrimLeadingNewline = false
+	style, ok := value.(Style)
+	if !ok {
+		return ctx
+	}
+	switch style.Type {
+	case StyleList:
+		ctx.IsInList = true
+		ctx.TrimTrailingNewlines = false
+	case StyleListItem, StyleParagraph:
+		ctx.IsInList = false
+		ctx.TrimTrailingNewlines = true
+	}
+	return ctx
+}
+
+// plainTextToHTML converts text outside any formatting entities to HTML.
+// Newlines next to block elements are dropped, as the block elements already
+// start and end lines.
+func (ctx formatContext) plainTextToHTML(text []rune, isLast, beforeBlock bool) string {
+	str := string(text)
+	if ctx.IsInList && strings.TrimSpace(str) == "" {
+		// Whitespace between list items can't be represented in HTML
+		return ""
+	}
+	if ctx.TrimLeadingNewline {
+		str = strings.TrimPrefix(str, "\n")
+	}
+	if beforeBlock || (isLast && ctx.TrimTrailingNewlines) {
+		str = strings.TrimRight(str, "\n")
+	}
+	return ctx.TextToHTML(str)
+}
+
 func (lrt *LinkedRangeTree) Format(message []rune, ctx formatContext) string {
 	if lrt == nil || lrt.Node == nil {
-		return ctx.TextToHTML(string(message))
+		return ctx.plainTextToHTML(message, true, false)
 	}
+	block := isBlock(lrt.Node.Value)
 	head := message[:lrt.Node.Start]
-	headStr := ctx.TextToHTML(string(head))
+	headStr := ctx.plainTextToHTML(head, false, block)
 	inner := message[lrt.Node.Start:lrt.Node.End()]
 	tail := message[lrt.Node.End():]
-	ourCtx := ctx
-	childMessage := lrt.Child.Format(inner, ourCtx)
+	childMessage := lrt.Child.Format(inner, ctx.childContext(lrt.Node.Value))
 	formattedChildMessage := lrt.Node.Value.Format(childMessage)
-	siblingMessage := lrt.Sibling.Format(tail, ctx)
+	siblingCtx := ctx
+	siblingCtx.TrimLeadingNewline = block
+	siblingMessage := lrt.Sibling.Format(tail, siblingCtx)
 	return headStr + formattedChildMessage + siblingMessage
 }
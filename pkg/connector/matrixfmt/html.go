@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 
 	"golang.org/x/exp/slices"
@@ -249,6 +250,10 @@ func (ctx Context) WithIncrementedListDepth() Context {
 // HTMLParser is a somewhat customizable Matrix HTML parser.
 type HTMLParser struct {
 	GetGhostDetails func(context.Context, id.UserID) (networkid.UserID, string, bool)
+	// UnicodeStyling makes the parser render bold and italic text with
+	// mathematical alphanumeric symbols and lists with bullets, as LinkedIn
+	// doesn't render any formatting in messages.
+	UnicodeStyling bool
 }
 
 // TaggedString is a string that also contains a HTML tag.
@@ -281,8 +286,53 @@ func Digits(num int) int {
 	return int(math.Floor(math.Log10(float64(num))) + 1)
 }
 
+func (parser *HTMLParser) listItemPrefix(node *html.Node) string {
+	if node.Parent == nil || node.Parent.Data != "ol" {
+		return "• "
+	}
+	number, err := strconv.Atoi(parser.getAttribute(node.Parent, "start"))
+	if err != nil {
+		number = 1
+	}
+	for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == "li" {
+			number++
+		}
+	}
+	return fmt.Sprintf("%d. ", number)
+}
+
+func (parser *HTMLParser) listItemToString(node *html.Node, str *EntityString) *EntityString {
+	prefix := parser.listItemPrefix(node)
+	// Indent the following lines (e.g. nested lists) to align with the text
+	// after the prefix.
+	indent := "\n" + strings.Repeat(" ", len([]rune(prefix)))
+	return NewEntityString(prefix).Append(JoinEntityString(indent, str.Split('\n')...).TrimSpace()).AppendString("\n")
+}
+
+func (parser *HTMLParser) blockquoteToString(node *html.Node, ctx Context) *EntityString {
+	lines := parser.nodeToTagAwareString(node.FirstChild, ctx).TrimSpace().Split('\n')
+	for i, line := range lines {
+		if len(line.String) == 0 {
+			lines[i] = NewEntityString(">")
+		} else {
+			lines[i] = NewEntityString("> ").Append(line)
+		}
+	}
+	return JoinEntityString("\n", lines...).TrimSpace()
+}
+
 func (parser *HTMLParser) basicFormatToString(node *html.Node, ctx Context) *EntityString {
 	str := parser.nodeToTagAwareString(node.FirstChild, ctx)
+	if parser.UnicodeStyling {
+		switch node.Data {
+		case "b", "strong", "i", "em":
+			// The text nodes inside were already styled
+			return str
+		case "li":
+			return parser.listItemToString(node, str)
+		}
+	}
 	switch node.Data {
 	case "b", "strong":
 		return NewEntityString("**").Append(str).AppendString("**")
@@ -319,7 +369,6 @@ func (parser *HTMLParser) linkToString(node *html.Node, ctx Context) *EntityStri
 			// Mention not allowed, use name as-is
 			return str
 		}
-		// FIXME this or GetGhostDetails needs to support non-ghost users too
 		userID, username, ok := parser.GetGhostDetails(ctx.Ctx, mxid)
 		if !ok {
 			return str
@@ -334,7 +383,7 @@ func (parser *HTMLParser) tagToString(node *html.Node, ctx Context) *EntityStrin
 	ctx = ctx.WithTag(node.Data)
 	switch node.Data {
 	case "blockquote":
-		return NewEntityString("> ").Append(parser.nodeToString(node.FirstChild, ctx))
+		return parser.blockquoteToString(node, ctx)
 	case "h1", "h2", "h3", "h4", "h5", "h6":
 		return parser.headerToString(node, ctx)
 	case "br":
@@ -358,7 +407,13 @@ func (parser *HTMLParser) singleNodeToString(node *html.Node, ctx Context) Tagge
 		if !ctx.PreserveWhitespace {
 			node.Data = strings.ReplaceAll(node.Data, "\n", "")
 		}
-		return TaggedString{NewEntityString(node.Data), "text"}
+		text := node.Data
+		if parser.UnicodeStyling && !ctx.TagStack.Has("code") {
+			bold := ctx.TagStack.Has("b") || ctx.TagStack.Has("strong")
+			italic := ctx.TagStack.Has("i") || ctx.TagStack.Has("em")
+			text = UnicodeStyle(text, bold, italic)
+		}
+		return TaggedString{NewEntityString(text), "text"}
 	case html.ElementNode:
 		return TaggedString{parser.tagToString(node, ctx), node.Data}
 	case html.DocumentNode:
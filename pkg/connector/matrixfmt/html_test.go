@@ -0,0 +1,72 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package matrixfmt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/linkedinfmt"
+	"go.mau.fi/mautrix-linkedin/pkg/connector/matrixfmt"
+)
+
+func TestParseUnicodeStyling(t *testing.T) {
+	parser := &matrixfmt.HTMLParser{
+		GetGhostDetails: func(ctx context.Context, userID id.UserID) (networkid.UserID, string, bool) {
+			return networkid.UserID(userID.Localpart()), "Alice", true
+		},
+		UnicodeStyling: true,
+	}
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"bold", "<b>Bold 42</b>", "𝗕𝗼𝗹𝗱 𝟰𝟮"},
+		{"italic", "<em>Italic 42</em>", "𝘐𝘵𝘢𝘭𝘪𝘤 42"},
+		{"bold italic", "<strong>a <i>b</i></strong>", "𝗮 𝙗"},
+		{"inline code", "<code>x</code>", "`x`"},
+		{"no styling in code", "<b><code>x</code></b>", "`x`"},
+		{"link", `<a href="https://example.com">text</a>`, "text (https://example.com)"},
+		{"blockquote", "<blockquote>quoted</blockquote>", "> quoted"},
+		{"multi-line blockquote", "<blockquote>first<br>second</blockquote>", "> first\n> second"},
+		{"blockquote paragraphs", "<blockquote><p>first</p><p><b>Bold</b></p></blockquote>", "> first\n>\n> 𝗕𝗼𝗹𝗱"},
+		{"unordered list", "<ul><li>one</li><li>two</li></ul>", "• one\n• two"},
+		{"ordered list", `<ol start="3"><li>one</li><li>two</li></ol>`, "3. one\n4. two"},
+		{"nested list", "<ul><li>one<ul><li>two</li></ul></li></ul>", "• one\n  • two"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed := parser.Parse(test.html, matrixfmt.NewContext(context.TODO()))
+			require.NotNil(t, parsed)
+			assert.Equal(t, test.expected, string(parsed.String))
+		})
+	}
+
+	t.Run("mention", func(t *testing.T) {
+		parsed := parser.Parse(`<b><a href="https://matrix.to/#/@alice:example.com">Alice</a></b>`, matrixfmt.NewContext(context.TODO()))
+		require.NotNil(t, parsed)
+		assert.Equal(t, "@Alice", string(parsed.String))
+		require.Len(t, parsed.Entities, 1)
+		assert.Equal(t, linkedinfmt.Mention{UserID: "alice"}, parsed.Entities[0].Value)
+	})
+}
@@ -0,0 +1,120 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package matrixfmt
+
+import (
+	"unicode"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// Split splits a message body into chunks of at most maxLength characters.
+// Chunks are split at paragraph, line or sentence boundaries if possible and
+// never in the middle of a mention. The attribute offsets are re-based to the
+// start of each chunk.
+func Split(body linkedingo.SendMessageBody, maxLength int) []linkedingo.SendMessageBody {
+	text := []rune(body.Text)
+	if maxLength <= 0 || len(text) <= maxLength {
+		return []linkedingo.SendMessageBody{body}
+	}
+	var chunks []linkedingo.SendMessageBody
+	start := 0
+	for len(text)-start > maxLength {
+		end := findSplitPoint(text, body.Attributes, start, start+maxLength)
+		chunks = append(chunks, makeChunk(text, body.Attributes, start, end))
+		start = end
+		for start < len(text) && unicode.IsSpace(text[start]) {
+			start++
+		}
+	}
+	if start < len(text) {
+		chunks = append(chunks, makeChunk(text, body.Attributes, start, len(text)))
+	}
+	return chunks
+}
+
+type splitMatcher func(text []rune, i int) bool
+
+var splitMatchers = []splitMatcher{
+	// Paragraphs
+	func(text []rune, i int) bool {
+		return text[i] == '\n' && i > 0 && text[i-1] == '\n'
+	},
+	// Lines
+	func(text []rune, i int) bool {
+		return text[i] == '\n'
+	},
+	// Sentences
+	func(text []rune, i int) bool {
+		return unicode.IsSpace(text[i]) && i > 0 && (text[i-1] == '.' || text[i-1] == '!' || text[i-1] == '?')
+	},
+	// Words
+	func(text []rune, i int) bool {
+		return unicode.IsSpace(text[i])
+	},
+}
+
+// findSplitPoint returns the index where the chunk starting at start should
+// end. The index is always after start and at most limit.
+func findSplitPoint(text []rune, attributes []linkedingo.SendMessageAttribute, start, limit int) int {
+	// Prefer the largest boundary type which doesn't make the chunk too short
+	minEnd := start + (limit-start)/2
+	for _, matcher := range splitMatchers {
+		for i := limit; i > start && i >= minEnd; i-- {
+			if i < len(text) && matcher(text, i) && !insideMention(attributes, i) {
+				return i
+			}
+		}
+	}
+	end := limit
+	for _, attr := range attributes {
+		if attr.AttributeKindUnion.Entity != nil && attr.Start < end && attr.Start+attr.Length > end && attr.Start > start {
+			end = attr.Start
+		}
+	}
+	return end
+}
+
+func insideMention(attributes []linkedingo.SendMessageAttribute, i int) bool {
+	for _, attr := range attributes {
+		if attr.AttributeKindUnion.Entity != nil && attr.Start < i && attr.Start+attr.Length > i {
+			return true
+		}
+	}
+	return false
+}
+
+func makeChunk(text []rune, attributes []linkedingo.SendMessageAttribute, start, end int) (chunk linkedingo.SendMessageBody) {
+	for end > start && unicode.IsSpace(text[end-1]) {
+		end--
+	}
+	chunk.Text = string(text[start:end])
+	for _, attr := range attributes {
+		attrStart := max(attr.Start, start)
+		attrEnd := min(attr.Start+attr.Length, end)
+		if attrEnd <= attrStart {
+			continue
+		} else if attr.AttributeKindUnion.Entity != nil && (attrStart != attr.Start || attrEnd != attr.Start+attr.Length) {
+			// Don't send partial mentions
+			continue
+		}
+		attr.Start = attrStart - start
+		attr.Length = attrEnd - attrStart
+		chunk.Attributes = append(chunk.Attributes, attr)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package matrixfmt_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/matrixfmt"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+func mention(start, length int) linkedingo.SendMessageAttribute {
+	return linkedingo.SendMessageAttribute{
+		Start:  start,
+		Length: length,
+		AttributeKindUnion: linkedingo.AttributeKind{
+			Entity: &linkedingo.Entity{URN: linkedingo.NewURN("urn:li:fsd_profile:ACoAA")},
+		},
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      linkedingo.SendMessageBody
+		maxLength int
+		expected  []linkedingo.SendMessageBody
+	}{
+		{
+			name:      "short",
+			body:      linkedingo.SendMessageBody{Text: "hello"},
+			maxLength: 10,
+			expected:  []linkedingo.SendMessageBody{{Text: "hello"}},
+		},
+		{
+			name:      "paragraphs",
+			body:      linkedingo.SendMessageBody{Text: "First paragraph.\n\nSecond one. More text"},
+			maxLength: 30,
+			expected:  []linkedingo.SendMessageBody{{Text: "First paragraph."}, {Text: "Second one. More text"}},
+		},
+		{
+			name:      "sentences",
+			body:      linkedingo.SendMessageBody{Text: "One sentence here. Another one follows"},
+			maxLength: 25,
+			expected:  []linkedingo.SendMessageBody{{Text: "One sentence here."}, {Text: "Another one follows"}},
+		},
+		{
+			name:      "words",
+			body:      linkedingo.SendMessageBody{Text: "aaaa bbbb cccc dddd"},
+			maxLength: 12,
+			expected:  []linkedingo.SendMessageBody{{Text: "aaaa bbbb"}, {Text: "cccc dddd"}},
+		},
+		{
+			name:      "hard cut",
+			body:      linkedingo.SendMessageBody{Text: "abcdefghij"},
+			maxLength: 4,
+			expected:  []linkedingo.SendMessageBody{{Text: "abcd"}, {Text: "efgh"}, {Text: "ij"}},
+		},
+		{
+			name: "mentions are rebased",
+			body: linkedingo.SendMessageBody{
+				Text:       "Hi @Alice\n@Bob Smith hello",
+				Attributes: []linkedingo.SendMessageAttribute{mention(3, 6), mention(10, 10)},
+			},
+			maxLength: 18,
+			expected: []linkedingo.SendMessageBody{
+				{Text: "Hi @Alice", Attributes: []linkedingo.SendMessageAttribute{mention(3, 6)}},
+				{Text: "@Bob Smith hello", Attributes: []linkedingo.SendMessageAttribute{mention(0, 10)}},
+			},
+		},
+		{
+			name: "no split inside mentions",
+			body: linkedingo.SendMessageBody{
+				Text:       "Hello there @Bob Smith",
+				Attributes: []linkedingo.SendMessageAttribute{mention(12, 10)},
+			},
+			maxLength: 18,
+			expected: []linkedingo.SendMessageBody{
+				{Text: "Hello there"},
+				{Text: "@Bob Smith", Attributes: []linkedingo.SendMessageAttribute{mention(0, 10)}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, matrixfmt.Split(test.body, test.maxLength))
+		})
+	}
+}
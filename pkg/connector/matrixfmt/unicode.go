@@ -0,0 +1,61 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package matrixfmt
+
+import (
+	"strings"
+)
+
+// The sans-serif variants of the mathematical alphanumeric symbols match the
+// font LinkedIn uses, and unlike the serif ones they don't have any holes
+// which are filled by letterlike symbols elsewhere in Unicode.
+const (
+	sansBoldUpper       = 0x1D5D4
+	sansBoldLower       = 0x1D5EE
+	sansItalicUpper     = 0x1D608
+	sansItalicLower     = 0x1D622
+	sansBoldItalicUpper = 0x1D63C
+	sansBoldItalicLower = 0x1D656
+	sansBoldDigit       = 0x1D7EC
+)
+
+// UnicodeStyle replaces ASCII letters and digits in the text with their
+// mathematical bold, italic or bold italic variants. There are no italic
+// digits, so digits are only changed for bold text.
+func UnicodeStyle(text string, bold, italic bool) string {
+	if !bold && !italic {
+		return text
+	}
+	upper, lower := rune(sansItalicUpper), rune(sansItalicLower)
+	if bold && italic {
+		upper, lower = sansBoldItalicUpper, sansBoldItalicLower
+	} else if bold {
+		upper, lower = sansBoldUpper, sansBoldLower
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return upper + r - 'A'
+		case r >= 'a' && r <= 'z':
+			return lower + r - 'a'
+		case bold && r >= '0' && r <= '9':
+			return sansBoldDigit + r - '0'
+		default:
+			return r
+		}
+	}, text)
+}
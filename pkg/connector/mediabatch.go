@@ -0,0 +1,265 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// mediaBatchMaxItems is the maximum number of images combined into a single
+// LinkedIn message.
+const mediaBatchMaxItems = 10
+
+// galleryCaption returns the caption of a gallery as a text message.
+func galleryCaption(content *event.MessageEventContent) *event.MessageEventContent {
+	caption := &event.MessageEventContent{
+		MsgType:  event.MsgText,
+		Body:     content.BeeperGalleryCaption,
+		Mentions: content.Mentions,
+	}
+	if content.BeeperGalleryCaptionHTML != "" {
+		caption.Format = event.FormatHTML
+		caption.FormattedBody = content.BeeperGalleryCaptionHTML
+	}
+	return caption
+}
+
+type mediaBatchItem struct {
+	msg           *bridgev2.MatrixMessage
+	renderContent linkedingo.SendRenderContent
+	body          linkedingo.SendMessageBody
+}
+
+// mediaBatch is a set of images sent from Matrix in quick succession, which
+// are sent to LinkedIn as a single message. Each Matrix event becomes a part
+// of the LinkedIn message.
+type mediaBatch struct {
+	ctx           context.Context
+	portalKey     networkid.PortalKey
+	sender        id.UserID
+	transactionID string
+	items         []*mediaBatchItem
+	timer         *time.Timer
+}
+
+func (mb *mediaBatch) hasCaption() bool {
+	for _, item := range mb.items {
+		if item.body.Text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// canBatchMedia returns true if the message should be added to a media batch
+// instead of being sent immediately.
+func (l *LinkedInClient) canBatchMedia(msg *bridgev2.MatrixMessage) bool {
+	return l.main.Config.MediaBatchWindow > 0 &&
+		msg.Content.MsgType == event.MsgImage &&
+		msg.ReplyTo == nil &&
+		!l.outbound.hasPending(msg.Portal.PortalKey)
+}
+
+// addToMediaBatch adds an uploaded image to the portal's media batch. The
+// batch is sent when no more images have been added to it within the batch
+// window.
+func (l *LinkedInClient) addToMediaBatch(ctx context.Context, msg *bridgev2.MatrixMessage, rc linkedingo.SendRenderContent, body linkedingo.SendMessageBody) (*bridgev2.MatrixMessageResponse, error) {
+	window := time.Duration(l.main.Config.MediaBatchWindow) * time.Millisecond
+	item := &mediaBatchItem{msg: msg, renderContent: rc, body: body}
+
+	l.mediaBatchesLock.Lock()
+	batch := l.mediaBatches[msg.Portal.PortalKey]
+	var flush *mediaBatch
+	if batch != nil && (batch.sender != msg.Event.Sender ||
+		len(batch.items) >= mediaBatchMaxItems ||
+		(body.Text != "" && batch.hasCaption())) {
+		// The image can't be added to the existing batch, so send the batch
+		// right away to keep the order of messages.
+		batch.timer.Stop()
+		delete(l.mediaBatches, batch.portalKey)
+		flush, batch = batch, nil
+	}
+	if batch == nil {
+		transactionID := string(msg.InputTransactionID)
+		if transactionID == "" {
+			transactionID = uuid.NewString()
+		}
+		batch = &mediaBatch{
+			ctx:           zerolog.Ctx(ctx).With().Str("media_batch_id", transactionID).Logger().WithContext(context.Background()),
+			portalKey:     msg.Portal.PortalKey,
+			sender:        msg.Event.Sender,
+			transactionID: transactionID,
+		}
+		// The rows for all images are saved when the batch is sent, so the
+		// echo only needs to be ignored.
+		msg.AddPendingToSave(&database.Message{SenderID: l.userID}, networkid.TransactionID(transactionID), func(_ bridgev2.RemoteMessage, _ *database.Message) (bool, error) {
+			return false, bridgev2.ErrNoStatus
+		})
+		batch.timer = time.AfterFunc(window, func() {
+			l.flushMediaBatch(batch)
+		})
+		l.mediaBatches[batch.portalKey] = batch
+	} else {
+		batch.timer.Reset(window)
+	}
+	batch.items = append(batch.items, item)
+	l.mediaBatchesLock.Unlock()
+
+	zerolog.Ctx(ctx).Debug().
+		Str("media_batch_id", batch.transactionID).
+		Int("batch_size", len(batch.items)).
+		Msg("Added image to media batch")
+	if flush != nil {
+		l.sendMediaBatch(flush)
+	}
+	return &bridgev2.MatrixMessageResponse{Pending: true}, nil
+}
+
+// flushMediaBatch sends the batch unless it was already sent.
+func (l *LinkedInClient) flushMediaBatch(batch *mediaBatch) {
+	l.mediaBatchesLock.Lock()
+	if l.mediaBatches[batch.portalKey] != batch {
+		l.mediaBatchesLock.Unlock()
+		return
+	}
+	delete(l.mediaBatches, batch.portalKey)
+	l.mediaBatchesLock.Unlock()
+	l.sendMediaBatch(batch)
+}
+
+// flushPortalMediaBatch sends the portal's media batch immediately, so that
+// a message which can't be batched isn't sent before it.
+func (l *LinkedInClient) flushPortalMediaBatch(portalKey networkid.PortalKey) {
+	l.mediaBatchesLock.Lock()
+	batch := l.mediaBatches[portalKey]
+	if batch == nil {
+		l.mediaBatchesLock.Unlock()
+		return
+	}
+	batch.timer.Stop()
+	delete(l.mediaBatches, portalKey)
+	l.mediaBatchesLock.Unlock()
+	l.sendMediaBatch(batch)
+}
+
+// stopMediaBatches moves the batches which haven't been sent yet to the
+// outbound queue, which sends them after reconnecting.
+func (l *LinkedInClient) stopMediaBatches() {
+	l.mediaBatchesLock.Lock()
+	batches := make([]*mediaBatch, 0, len(l.mediaBatches))
+	for _, batch := range l.mediaBatches {
+		batch.timer.Stop()
+		batches = append(batches, batch)
+	}
+	clear(l.mediaBatches)
+	l.mediaBatchesLock.Unlock()
+	for _, batch := range batches {
+		l.enqueueOutbound(batch.ctx, newQueuedBatch(batch), nil)
+	}
+}
+
+// newQueuedBatch combines the images of a batch into a single queued message.
+func newQueuedBatch(batch *mediaBatch) *QueuedMessage {
+	var body linkedingo.SendMessageBody
+	renderContent := make([]linkedingo.SendRenderContent, len(batch.items))
+	for i, item := range batch.items {
+		renderContent[i] = item.renderContent
+		if item.body.Text != "" {
+			body = item.body
+		}
+	}
+	qm := newQueuedMessage(batch.items[0].msg, body, renderContent, batch.transactionID)
+	for _, item := range batch.items[1:] {
+		qm.BatchEvents = append(qm.BatchEvents, QueuedBatchEvent{
+			EventID:     item.msg.Event.ID,
+			MatrixTxnID: item.msg.Event.Unsigned.TransactionID,
+			Timestamp:   jsontime.UM(time.UnixMilli(item.msg.Event.Timestamp)),
+		})
+	}
+	return qm
+}
+
+func (l *LinkedInClient) sendMediaBatch(batch *mediaBatch) {
+	ctx := batch.ctx
+	log := zerolog.Ctx(ctx)
+	qm := newQueuedBatch(batch)
+	if l.outbound.hasPending(batch.portalKey) {
+		l.enqueueOutbound(ctx, qm, nil)
+		return
+	}
+
+	resp, parts, err := l.sendMessageParts(ctx, qm.msg, l.conversationURN(batch.portalKey.ID), qm.Body, qm.RenderContent, qm.TransactionID)
+	if isRetriableSendError(err) {
+		l.enqueueOutbound(ctx, qm, err)
+		return
+	} else if err != nil {
+		log.Err(err).Int("batch_size", len(batch.items)).Msg("Failed to send media batch")
+		l.recallMessageParts(ctx, resp, parts)
+		qm.msg.RemovePending(networkid.TransactionID(qm.TransactionID))
+		ms := bridgev2.WrapErrorInStatus(err).
+			WithStatus(event.MessageStatusFail).
+			WithIsCertain(true).
+			WithSendNotice(true).
+			WithErrorAsMessage()
+		l.sendQueuedStatus(ctx, qm, ms, 0)
+		return
+	}
+	l.saveSentBatch(ctx, qm, resp, parts)
+	log.Info().
+		Stringer("message_urn", resp.Data.EntityURN).
+		Int("batch_size", len(batch.items)).
+		Msg("Sent media batch")
+}
+
+// saveSentBatch saves each image of a sent media batch as a part of the
+// LinkedIn message and sends the success statuses.
+func (l *LinkedInClient) saveSentBatch(ctx context.Context, qm *QueuedMessage, resp *linkedingo.MessageSentResponse, parts []*database.Message) {
+	splitParts := l.saveSplitParts(ctx, qm.Sender, parts)
+	msgID := l.messageID(qm.PortalKey, resp.Data.EntityURN)
+	for i, evt := range qm.events() {
+		dbMessage := &database.Message{
+			ID:         msgID,
+			PartID:     networkid.PartID(fmt.Sprintf("part_%d", i)),
+			MXID:       evt.ID,
+			Room:       qm.PortalKey,
+			SenderID:   l.userID,
+			SenderMXID: qm.Sender,
+			Timestamp:  resp.Data.DeliveredAt.Time,
+			Metadata:   &MessageMetadata{},
+		}
+		if i == 0 {
+			dbMessage.Metadata.(*MessageMetadata).SplitParts = splitParts
+		}
+		if err := l.main.Bridge.DB.Message.Insert(ctx, dbMessage); err != nil {
+			zerolog.Ctx(ctx).Err(err).Stringer("event_id", evt.ID).Msg("Failed to save batched image to database")
+		}
+	}
+	l.sendQueuedStatus(ctx, qm, bridgev2.MessageStatus{Status: event.MessageStatusSuccess}, resp.Data.DeliveredAt.UnixMilli())
+}
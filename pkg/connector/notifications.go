@@ -0,0 +1,244 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// notificationsPortalID is the ID of the per-login portal which receives
+// LinkedIn notifications.
+const notificationsPortalID networkid.PortalID = "notifications"
+
+var ErrNotificationsRoomReadOnly = bridgev2.WrapErrorInStatus(errors.New("the notifications room is read-only")).
+	WithSendNotice(true).WithIsCertain(true).WithErrorAsMessage()
+
+func isNotificationsPortal(portal *bridgev2.Portal) bool {
+	return portal != nil && portal.ID == notificationsPortalID
+}
+
+func (l *LinkedInClient) notificationsPortalKey() networkid.PortalKey {
+	return networkid.PortalKey{
+		ID:       notificationsPortalID,
+		Receiver: l.userLogin.ID,
+	}
+}
+
+func (l *LinkedInClient) notificationsChatInfo() *bridgev2.ChatInfo {
+	return &bridgev2.ChatInfo{
+		Name:        ptr.Ptr("LinkedIn Notifications"),
+		Topic:       ptr.Ptr("Profile views, post reactions, job alerts and connection requests from LinkedIn"),
+		Type:        ptr.Ptr(database.RoomTypeDefault),
+		CanBackfill: true,
+		Members: &bridgev2.ChatMemberList{
+			IsFull: true,
+			MemberMap: map[networkid.UserID]bridgev2.ChatMember{
+				l.userID: {
+					EventSender: bridgev2.EventSender{
+						IsFromMe:    true,
+						Sender:      l.userID,
+						SenderLogin: l.userLogin.ID,
+					},
+					Membership: event.MembershipJoin,
+				},
+			},
+			PowerLevels: &bridgev2.PowerLevelOverrides{
+				EventsDefault: ptr.Ptr(moderatorPL),
+			},
+		},
+	}
+}
+
+func (l *LinkedInClient) syncNotificationsPortal() {
+	if !l.main.Config.NotificationsRoom {
+		return
+	}
+	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.ChatResync{
+		EventMeta: simplevent.EventMeta{
+			Type:         bridgev2.RemoteEventChatResync,
+			PortalKey:    l.notificationsPortalKey(),
+			CreatePortal: true,
+		},
+		ChatInfo: l.notificationsChatInfo(),
+	})
+}
+
+// notificationsCatchupCount is the number of notification cards fetched when
+// an in-app alert arrives.
+const notificationsCatchupCount = 10
+
+// onRealtimeInAppAlert bridges new notification cards when an in-app alert
+// arrives. The alert itself isn't bridged, because its URN differs from the
+// URN of the card for the same notification, and backfill can only fetch
+// cards.
+func (l *LinkedInClient) onRealtimeInAppAlert(ctx context.Context, _ *linkedingo.DecoratedEvent, alert *linkedingo.InAppAlert) {
+	if alert == nil || !l.main.Config.NotificationsRoom {
+		return
+	}
+	log := zerolog.Ctx(ctx).With().Stringer("alert_urn", alert.EntityURN).Logger()
+
+	cards, err := l.client.GetNotifications(ctx, 0, notificationsCatchupCount)
+	if err != nil {
+		log.Err(err).Msg("Failed to get notifications for in-app alert")
+		return
+	}
+	// Cards are returned newest first, so every card before the first one
+	// that was already bridged is new.
+	var newNotifications []linkedingo.Notification
+	foundBridged := false
+	for _, card := range cards.Elements {
+		notification := card.Notification()
+		if notification.EntityURN.IsEmpty() {
+			continue
+		}
+		existing, err := l.main.Bridge.DB.Message.GetFirstPartByID(ctx, l.userLogin.ID, networkid.MessageID(notification.EntityURN.String()))
+		if err != nil {
+			log.Err(err).Msg("Failed to check if notification was already bridged")
+			return
+		} else if existing != nil {
+			foundBridged = true
+			break
+		}
+		newNotifications = append(newNotifications, notification)
+	}
+	if !foundBridged {
+		// Nothing on the page was bridged before, so older cards are left to
+		// backfill and only the ones from this alert are bridged.
+		newNotifications = slices.DeleteFunc(newNotifications, func(notification linkedingo.Notification) bool {
+			return notification.PublishedAt.Before(alert.PublishedAt.Time)
+		})
+	}
+
+	for _, notification := range slices.Backward(newNotifications) {
+		l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Message[linkedingo.Notification]{
+			EventMeta: simplevent.EventMeta{
+				Type: bridgev2.RemoteEventMessage,
+				LogContext: func(c zerolog.Context) zerolog.Context {
+					return c.Stringer("notification_urn", notification.EntityURN)
+				},
+				PortalKey:    l.notificationsPortalKey(),
+				Timestamp:    notification.PublishedAt,
+				CreatePortal: true,
+			},
+			ID:                 networkid.MessageID(notification.EntityURN.String()),
+			Data:               notification,
+			ConvertMessageFunc: l.convertNotificationToMatrix,
+		})
+	}
+}
+
+func (l *LinkedInClient) convertNotificationToMatrix(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, notification linkedingo.Notification) (*bridgev2.ConvertedMessage, error) {
+	content := &event.MessageEventContent{
+		MsgType: event.MsgNotice,
+		Body:    notification.Headline,
+	}
+	if notification.SubHeadline != "" {
+		content.Body += "\n" + notification.SubHeadline
+	}
+	if notification.URL != "" {
+		content.Body += "\n" + notification.URL
+		content.Format = event.FormatHTML
+		content.FormattedBody = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(notification.URL), event.TextToHTML(notification.Headline))
+		if notification.SubHeadline != "" {
+			content.FormattedBody += "<br>" + event.TextToHTML(notification.SubHeadline)
+		}
+
+		linkPreview := &event.BeeperLinkPreview{
+			LinkPreview: event.LinkPreview{
+				CanonicalURL: notification.URL,
+				Title:        notification.Headline,
+				Description:  notification.SubHeadline,
+			},
+			MatchedURL: notification.URL,
+		}
+		if notification.ImageURL != "" {
+			var err error
+			linkPreview.ImageURL, linkPreview.ImageEncryption, err = intent.UploadMediaStream(ctx, portal.MXID, 0, false, func(w io.Writer) (*bridgev2.FileStreamResult, error) {
+				err := l.client.Download(ctx, w, notification.ImageURL)
+				if err != nil {
+					return nil, err
+				}
+				return &bridgev2.FileStreamResult{
+					MimeType: "image/jpeg",
+					FileName: "thumbnail.jpeg",
+				}, nil
+			})
+			if err != nil {
+				zerolog.Ctx(ctx).Err(err).Msg("failed to upload notification thumbnail")
+			}
+		}
+		content.BeeperLinkPreviews = []*event.BeeperLinkPreview{linkPreview}
+	}
+	return &bridgev2.ConvertedMessage{
+		Parts: []*bridgev2.ConvertedMessagePart{{Type: event.EventMessage, Content: content}},
+	}, nil
+}
+
+func (l *LinkedInClient) fetchNotifications(ctx context.Context, portal *bridgev2.Portal, fetchParams bridgev2.FetchMessagesParams) (*bridgev2.FetchMessagesResponse, error) {
+	if fetchParams.Forward && fetchParams.AnchorMessage != nil {
+		// New cards are bridged when in-app alerts arrive, so only history
+		// is filled here.
+		return &bridgev2.FetchMessagesResponse{Forward: true}, nil
+	}
+	start, _ := strconv.Atoi(string(fetchParams.Cursor))
+	cards, err := l.client.GetNotifications(ctx, start, fetchParams.Count)
+	if err != nil {
+		return nil, err
+	}
+	resp := bridgev2.FetchMessagesResponse{
+		Forward:  fetchParams.Forward,
+		MarkRead: true,
+		Cursor:   networkid.PaginationCursor(strconv.Itoa(start + len(cards.Elements))),
+		HasMore:  len(cards.Elements) > 0 && (cards.Paging.Total == 0 || start+len(cards.Elements) < cards.Paging.Total),
+	}
+	intent := l.main.Bridge.Bot
+	// Cards are returned newest first, but backfill messages must be in
+	// chronological order.
+	for i := len(cards.Elements) - 1; i >= 0; i-- {
+		notification := cards.Elements[i].Notification()
+		if notification.EntityURN.IsEmpty() {
+			continue
+		}
+		converted, err := l.convertNotificationToMatrix(ctx, portal, intent, notification)
+		if err != nil {
+			return nil, err
+		}
+		resp.Messages = append(resp.Messages, &bridgev2.BackfillMessage{
+			ConvertedMessage: converted,
+			ID:               networkid.MessageID(notification.EntityURN.String()),
+			Timestamp:        notification.PublishedAt,
+			StreamOrder:      notification.PublishedAt.UnixMilli(),
+		})
+	}
+	return &resp, nil
+}
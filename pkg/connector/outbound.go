@@ -0,0 +1,484 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+const (
+	// outboundRetryInitialDelay is how long to wait before the first retry of
+	// a queued message. The delay doubles after every attempt.
+	outboundRetryInitialDelay = 15 * time.Second
+	// outboundRetryMaxDelay caps the delay between retries.
+	outboundRetryMaxDelay = 10 * time.Minute
+	// outboundMaxAttempts is how many times a queued message is sent before
+	// it's marked as failed.
+	outboundMaxAttempts = 10
+)
+
+var ErrOutboundRetriesExhausted = bridgev2.WrapErrorInStatus(errors.New("failed to send message after multiple attempts")).
+	WithStatus(event.MessageStatusFail).WithIsCertain(true).WithSendNotice(true).WithErrorAsMessage()
+
+// QueuedMessage is a Matrix message which couldn't be sent to LinkedIn yet.
+// Queued messages are stored in the login metadata so that they survive
+// restarts.
+type QueuedMessage struct {
+	PortalKey     networkid.PortalKey            `json:"portal_key"`
+	RoomID        id.RoomID                      `json:"room_id"`
+	EventID       id.EventID                     `json:"event_id"`
+	Sender        id.UserID                      `json:"sender"`
+	MsgType       event.MessageType              `json:"msgtype,omitempty"`
+	MatrixTxnID   string                         `json:"matrix_txn_id,omitempty"`
+	Timestamp     jsontime.UnixMilli             `json:"timestamp"`
+	ReplyTo       networkid.MessageID            `json:"reply_to,omitempty"`
+	Body          linkedingo.SendMessageBody     `json:"body"`
+	RenderContent []linkedingo.SendRenderContent `json:"render_content,omitempty"`
+	TransactionID string                         `json:"transaction_id"`
+	Attempts      int                            `json:"attempts,omitempty"`
+	LastError     string                         `json:"last_error,omitempty"`
+	BatchEvents   []QueuedBatchEvent             `json:"batch_events,omitempty"`
+
+	msg  *bridgev2.MatrixMessage
+	sent bool
+}
+
+func (qm *QueuedMessage) event() *event.Event {
+	return &event.Event{
+		ID:        qm.EventID,
+		RoomID:    qm.RoomID,
+		Sender:    qm.Sender,
+		Type:      event.EventMessage,
+		Timestamp: qm.Timestamp.UnixMilli(),
+		Content:   event.Content{Parsed: &event.MessageEventContent{MsgType: qm.MsgType}},
+		Unsigned:  event.Unsigned{TransactionID: qm.MatrixTxnID},
+	}
+}
+
+// QueuedBatchEvent is an image of a media batch other than the first one,
+// which is sent as a part of the same LinkedIn message.
+type QueuedBatchEvent struct {
+	EventID     id.EventID         `json:"event_id"`
+	MatrixTxnID string             `json:"matrix_txn_id,omitempty"`
+	Timestamp   jsontime.UnixMilli `json:"timestamp"`
+}
+
+// events returns all Matrix events which the message is sent for.
+func (qm *QueuedMessage) events() []*event.Event {
+	evts := []*event.Event{qm.event()}
+	for _, batchEvt := range qm.BatchEvents {
+		evt := qm.event()
+		evt.ID = batchEvt.EventID
+		evt.Timestamp = batchEvt.Timestamp.UnixMilli()
+		evt.Unsigned.TransactionID = batchEvt.MatrixTxnID
+		evts = append(evts, evt)
+	}
+	return evts
+}
+
+func (qm *QueuedMessage) retryDelay() time.Duration {
+	if qm.Attempts == 0 {
+		return 0
+	}
+	delay := outboundRetryInitialDelay << (qm.Attempts - 1)
+	if delay <= 0 || delay > outboundRetryMaxDelay {
+		delay = outboundRetryMaxDelay
+	}
+	return delay
+}
+
+// outboundQueue holds the messages waiting to be sent to LinkedIn. Each portal
+// has its own queue which is drained in order by a single worker, so that a
+// message is never delivered before the ones sent earlier in the same chat.
+type outboundQueue struct {
+	lock    sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	queues  map[networkid.PortalKey][]*QueuedMessage
+	running map[networkid.PortalKey]bool
+}
+
+func newOutboundQueue() *outboundQueue {
+	return &outboundQueue{
+		queues:  map[networkid.PortalKey][]*QueuedMessage{},
+		running: map[networkid.PortalKey]bool{},
+	}
+}
+
+func (oq *outboundQueue) hasPending(portalKey networkid.PortalKey) bool {
+	oq.lock.Lock()
+	defer oq.lock.Unlock()
+	return len(oq.queues[portalKey]) > 0
+}
+
+// push adds a message to the end of its portal's queue. The caller must hold
+// the queue lock.
+func (oq *outboundQueue) push(qm *QueuedMessage) {
+	oq.queues[qm.PortalKey] = append(oq.queues[qm.PortalKey], qm)
+}
+
+// peek returns the first message in the portal's queue, or nil if the queue
+// is empty. The caller must hold the queue lock.
+func (oq *outboundQueue) peek(portalKey networkid.PortalKey) *QueuedMessage {
+	if queue := oq.queues[portalKey]; len(queue) > 0 {
+		return queue[0]
+	}
+	return nil
+}
+
+// pop removes the first message from the portal's queue. The caller must hold
+// the queue lock.
+func (oq *outboundQueue) pop(portalKey networkid.PortalKey) {
+	if queue := oq.queues[portalKey]; len(queue) > 0 {
+		oq.queues[portalKey] = queue[1:]
+	}
+}
+
+// snapshot returns copies of all queued messages, in order within each
+// portal. The copies can be stored in the login metadata while the queue
+// keeps updating the originals. The caller must hold the queue lock.
+func (oq *outboundQueue) snapshot() []*QueuedMessage {
+	var queued []*QueuedMessage
+	for _, queue := range oq.queues {
+		for _, qm := range queue {
+			qmCopy := *qm
+			queued = append(queued, &qmCopy)
+		}
+	}
+	return queued
+}
+
+// Stop stops all workers. The queued messages are kept and resumed on the
+// next connect.
+func (oq *outboundQueue) Stop() {
+	oq.lock.Lock()
+	defer oq.lock.Unlock()
+	if oq.cancel != nil {
+		oq.cancel()
+	}
+	oq.ctx = nil
+	oq.cancel = nil
+	clear(oq.running)
+}
+
+// isRetriableSendError returns true if sending a message failed in a way that
+// may go away by itself, like a network error or a LinkedIn server error.
+// Other errors, like failing to decode a successful response, aren't retried,
+// as the message may have been delivered.
+func isRetriableSendError(err error) bool {
+	var statusErr linkedingo.UnexpectedStatusError
+	var urlErr *url.Error
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
+
+func (l *LinkedInClient) sendQueuedStatus(ctx context.Context, qm *QueuedMessage, ms bridgev2.MessageStatus, streamOrder int64) {
+	for _, evt := range qm.events() {
+		info := bridgev2.StatusEventInfoFromEvent(evt)
+		info.StreamOrder = streamOrder
+		l.main.Bridge.Matrix.SendMessageStatus(ctx, &ms, info)
+	}
+}
+
+// saveOutboundQueue stores the queued messages in the login metadata.
+// The caller must hold the queue lock.
+func (l *LinkedInClient) saveOutboundQueue(ctx context.Context) {
+	queued := l.outbound.snapshot()
+	err := l.updateLoginMetadata(ctx, func(meta *UserLoginMetadata) {
+		meta.OutboundQueue = queued
+	})
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to save outbound message queue")
+	}
+}
+
+// queueOutboundMessage adds a message to the end of the portal's queue.
+func (l *LinkedInClient) queueOutboundMessage(ctx context.Context, msg *bridgev2.MatrixMessage, body linkedingo.SendMessageBody, renderContent []linkedingo.SendRenderContent, transactionID string, sendErr error) (*bridgev2.MatrixMessageResponse, error) {
+	l.enqueueOutbound(ctx, newQueuedMessage(msg, body, renderContent, transactionID), sendErr)
+	return &bridgev2.MatrixMessageResponse{Pending: true}, nil
+}
+
+func newQueuedMessage(msg *bridgev2.MatrixMessage, body linkedingo.SendMessageBody, renderContent []linkedingo.SendRenderContent, transactionID string) *QueuedMessage {
+	qm := &QueuedMessage{
+		PortalKey:     msg.Portal.PortalKey,
+		RoomID:        msg.Event.RoomID,
+		EventID:       msg.Event.ID,
+		Sender:        msg.Event.Sender,
+		MsgType:       msg.Content.MsgType,
+		MatrixTxnID:   msg.Event.Unsigned.TransactionID,
+		Timestamp:     jsontime.UM(time.UnixMilli(msg.Event.Timestamp)),
+		Body:          body,
+		RenderContent: renderContent,
+		TransactionID: transactionID,
+		msg:           msg,
+	}
+	if msg.ReplyTo != nil {
+		qm.ReplyTo = msg.ReplyTo.ID
+	}
+	return qm
+}
+
+// enqueueOutbound adds a message to the end of the portal's queue. The
+// message is registered as pending, so that a remote echo which arrives
+// before the queue gets a response is matched to the Matrix event.
+func (l *LinkedInClient) enqueueOutbound(ctx context.Context, qm *QueuedMessage, sendErr error) {
+	ms := bridgev2.MessageStatus{Status: event.MessageStatusPending, Message: "Waiting for earlier messages to be sent"}
+	if sendErr != nil {
+		qm.Attempts = 1
+		qm.LastError = sendErr.Error()
+		ms = bridgev2.WrapErrorInStatus(sendErr).WithStatus(event.MessageStatusPending).WithMessage("Sending failed, will retry")
+	}
+	l.registerPendingOutbound(qm)
+
+	l.outbound.lock.Lock()
+	l.outbound.push(qm)
+	l.saveOutboundQueue(ctx)
+	l.startOutboundWorker(qm.PortalKey)
+	l.outbound.lock.Unlock()
+
+	zerolog.Ctx(ctx).Debug().
+		AnErr("send_error", sendErr).
+		Str("transaction_id", qm.TransactionID).
+		Msg("Queued outbound message")
+	l.sendQueuedStatus(ctx, qm, ms, 0)
+}
+
+func (l *LinkedInClient) registerPendingOutbound(qm *QueuedMessage) {
+	qm.msg.AddPendingToSave(&database.Message{
+		SenderID: l.userID,
+	}, networkid.TransactionID(qm.TransactionID), func(_ bridgev2.RemoteMessage, _ *database.Message) (bool, error) {
+		if len(qm.BatchEvents) > 0 {
+			// The queue saves the images of a media batch as separate parts.
+			return false, bridgev2.ErrNoStatus
+		}
+		l.outbound.lock.Lock()
+		defer l.outbound.lock.Unlock()
+		if qm.sent {
+			// The queue already saved the message.
+			return false, bridgev2.ErrNoStatus
+		}
+		qm.sent = true
+		return true, nil
+	})
+}
+
+// resumeOutboundQueue restores the queued messages from the login metadata
+// and starts the workers.
+func (l *LinkedInClient) resumeOutboundQueue(ctx context.Context) {
+	log := zerolog.Ctx(ctx)
+	l.outbound.lock.Lock()
+	defer l.outbound.lock.Unlock()
+	if l.outbound.cancel != nil {
+		l.outbound.cancel()
+	}
+	l.outbound.ctx, l.outbound.cancel = context.WithCancel(log.With().Str("component", "outbound_queue").Logger().WithContext(context.Background()))
+	clear(l.outbound.running)
+
+	if len(l.outbound.queues) == 0 {
+		for _, stored := range l.getLoginMetadata().OutboundQueue {
+			// The stored messages are snapshots, the queue works on copies.
+			qm := new(QueuedMessage)
+			*qm = *stored
+			portal, err := l.main.Bridge.GetExistingPortalByKey(ctx, qm.PortalKey)
+			if err != nil {
+				log.Err(err).Stringer("event_id", qm.EventID).Msg("Failed to get portal of queued message")
+				continue
+			} else if portal == nil {
+				log.Warn().Stringer("event_id", qm.EventID).Msg("Dropping queued message for deleted portal")
+				continue
+			}
+			qm.msg = &bridgev2.MatrixMessage{
+				MatrixEventBase: bridgev2.MatrixEventBase[*event.MessageEventContent]{
+					Event:   qm.event(),
+					Content: qm.event().Content.AsMessage(),
+					Portal:  portal,
+				},
+			}
+			if qm.ReplyTo != "" {
+				qm.msg.ReplyTo = &database.Message{ID: qm.ReplyTo}
+			}
+			l.registerPendingOutbound(qm)
+			l.outbound.push(qm)
+		}
+		if len(l.outbound.queues) > 0 {
+			log.Info().Int("portal_count", len(l.outbound.queues)).Msg("Resuming outbound message queue")
+		}
+	}
+	for portalKey := range l.outbound.queues {
+		l.startOutboundWorker(portalKey)
+	}
+}
+
+// startOutboundWorker starts draining the queue of the given portal unless
+// it's already being drained or the client isn't connected. The caller must
+// hold the queue lock.
+func (l *LinkedInClient) startOutboundWorker(portalKey networkid.PortalKey) {
+	if l.outbound.ctx == nil || l.outbound.running[portalKey] {
+		return
+	}
+	l.outbound.running[portalKey] = true
+	go l.runOutboundWorker(l.outbound.ctx, portalKey)
+}
+
+func (l *LinkedInClient) runOutboundWorker(ctx context.Context, portalKey networkid.PortalKey) {
+	log := zerolog.Ctx(ctx).With().Object("portal_key", portalKey).Logger()
+	ctx = log.WithContext(ctx)
+	for {
+		l.outbound.lock.Lock()
+		qm := l.outbound.peek(portalKey)
+		if qm == nil || ctx.Err() != nil {
+			if ctx.Err() == nil {
+				delete(l.outbound.running, portalKey)
+				delete(l.outbound.queues, portalKey)
+			}
+			l.outbound.lock.Unlock()
+			return
+		}
+		l.outbound.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(qm.retryDelay()):
+		}
+		done := l.sendQueuedMessage(ctx, qm)
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.outbound.lock.Lock()
+		if done {
+			l.outbound.pop(portalKey)
+		}
+		l.saveOutboundQueue(ctx)
+		l.outbound.lock.Unlock()
+	}
+}
+
+// sendQueuedMessage makes one attempt at sending a queued message. It returns
+// true if the message should be removed from the queue.
+func (l *LinkedInClient) sendQueuedMessage(ctx context.Context, qm *QueuedMessage) bool {
+	log := zerolog.Ctx(ctx).With().
+		Stringer("event_id", qm.EventID).
+		Str("transaction_id", qm.TransactionID).
+		Int("attempt", qm.Attempts+1).
+		Logger()
+	resp, parts, err := l.sendMessageParts(ctx, qm.msg, l.conversationURN(qm.PortalKey.ID), qm.Body, qm.RenderContent, qm.TransactionID)
+	if ctx.Err() != nil {
+		return false
+	} else if err != nil {
+		l.outbound.lock.Lock()
+		qm.Attempts++
+		qm.LastError = err.Error()
+		alreadySaved := qm.sent
+		l.outbound.lock.Unlock()
+		if alreadySaved {
+			// The echo was already saved and marked as sent, so the message
+			// was delivered even though the response wasn't received.
+			log.Debug().Err(err).Msg("Dropping queued message which was already delivered")
+			qm.msg.RemovePending(networkid.TransactionID(qm.TransactionID))
+			return true
+		} else if !isRetriableSendError(err) || qm.Attempts >= outboundMaxAttempts {
+			log.Err(err).Msg("Failed to send queued message, giving up")
+			l.recallMessageParts(ctx, resp, parts)
+			qm.msg.RemovePending(networkid.TransactionID(qm.TransactionID))
+			ms := bridgev2.WrapErrorInStatus(err).
+				WithStatus(event.MessageStatusFail).
+				WithIsCertain(true).
+				WithSendNotice(true).
+				WithErrorAsMessage()
+			if isRetriableSendError(err) {
+				ms = ErrOutboundRetriesExhausted
+				ms.InternalError = err
+			}
+			ms.RetryNum = qm.Attempts
+			l.sendQueuedStatus(ctx, qm, ms, 0)
+			return true
+		}
+		log.Warn().Err(err).Dur("retry_in", qm.retryDelay()).Msg("Failed to send queued message, will retry")
+		ms := bridgev2.WrapErrorInStatus(err).
+			WithStatus(event.MessageStatusPending).
+			WithMessage(fmt.Sprintf("Sending failed, retrying (attempt %d of %d)", qm.Attempts+1, outboundMaxAttempts))
+		ms.RetryNum = qm.Attempts
+		l.sendQueuedStatus(ctx, qm, ms, 0)
+		return false
+	}
+
+	if len(qm.BatchEvents) > 0 {
+		l.saveSentBatch(ctx, qm, resp, parts)
+		qm.msg.RemovePending(networkid.TransactionID(qm.TransactionID))
+		log.Info().Stringer("message_urn", resp.Data.EntityURN).Msg("Sent queued media batch")
+		return true
+	}
+	splitParts := l.saveSplitParts(ctx, qm.Sender, parts)
+	l.outbound.lock.Lock()
+	alreadySaved := qm.sent
+	qm.sent = true
+	l.outbound.lock.Unlock()
+	if alreadySaved && len(splitParts) > 0 {
+		// The echo of the first part arrived before the other parts were sent
+		if dbMessage, err := l.main.Bridge.DB.Message.GetPartByMXID(ctx, qm.EventID); err != nil || dbMessage == nil {
+			log.Err(err).Msg("Failed to get queued message from database to save split parts")
+		} else {
+			dbMessage.Metadata.(*MessageMetadata).SplitParts = splitParts
+			if err = l.main.Bridge.DB.Message.Update(ctx, dbMessage); err != nil {
+				log.Err(err).Msg("Failed to save split parts of queued message")
+			}
+		}
+	}
+	if !alreadySaved {
+		dbMessage := &database.Message{
+			ID:         l.messageID(qm.PortalKey, resp.Data.EntityURN),
+			MXID:       qm.EventID,
+			Room:       qm.PortalKey,
+			SenderID:   l.userID,
+			SenderMXID: qm.Sender,
+			Timestamp:  resp.Data.DeliveredAt.Time,
+			Metadata:   &MessageMetadata{SplitParts: splitParts},
+		}
+		if qm.ReplyTo != "" {
+			dbMessage.ReplyTo.MessageID = qm.ReplyTo
+		}
+		if err = l.main.Bridge.DB.Message.Insert(ctx, dbMessage); err != nil {
+			log.Err(err).Msg("Failed to save queued message to database")
+		}
+	}
+	qm.msg.RemovePending(networkid.TransactionID(qm.TransactionID))
+	if !alreadySaved {
+		l.sendQueuedStatus(ctx, qm, bridgev2.MessageStatus{Status: event.MessageStatusSuccess}, resp.Data.DeliveredAt.UnixMilli())
+	}
+	log.Info().Stringer("message_urn", resp.Data.EntityURN).Msg("Sent queued message")
+	return true
+}
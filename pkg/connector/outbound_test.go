@@ -0,0 +1,140 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+func TestQueuedMessageRetryDelay(t *testing.T) {
+	for attempts, expected := range []time.Duration{
+		0,
+		15 * time.Second,
+		30 * time.Second,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		10 * time.Minute,
+		10 * time.Minute,
+	} {
+		t.Run(fmt.Sprintf("attempts=%d", attempts), func(t *testing.T) {
+			qm := &connector.QueuedMessage{Attempts: attempts}
+			assert.Equal(t, expected, qm.RetryDelay())
+		})
+	}
+	// The shift overflows for large attempt counts, which must still be capped.
+	assert.Equal(t, 10*time.Minute, (&connector.QueuedMessage{Attempts: 100}).RetryDelay())
+}
+
+func TestIsRetriableSendError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		retriable bool
+	}{
+		{"server error", linkedingo.UnexpectedStatusError{StatusCode: http.StatusBadGateway}, true},
+		{"rate limited", linkedingo.UnexpectedStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"wrapped server error", fmt.Errorf("failed to send: %w", linkedingo.UnexpectedStatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"client error", linkedingo.UnexpectedStatusError{StatusCode: http.StatusBadRequest}, false},
+		{"network error", &url.Error{Op: "Post", URL: "https://www.linkedin.com", Err: errors.New("connection reset")}, true},
+		{"cancelled", &url.Error{Op: "Post", URL: "https://www.linkedin.com", Err: context.Canceled}, false},
+		{"other error", errors.New("invalid message"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.retriable, connector.IsRetriableSendError(tc.err))
+		})
+	}
+}
+
+func TestOutboundQueueOrder(t *testing.T) {
+	portalA := networkid.PortalKey{ID: "a"}
+	portalB := networkid.PortalKey{ID: "b"}
+	q := connector.NewOutboundQueue()
+	q.Push(&connector.QueuedMessage{PortalKey: portalA, TransactionID: "a1"})
+	q.Push(&connector.QueuedMessage{PortalKey: portalB, TransactionID: "b1"})
+	q.Push(&connector.QueuedMessage{PortalKey: portalA, TransactionID: "a2"})
+	q.Push(&connector.QueuedMessage{PortalKey: portalA, TransactionID: "a3"})
+
+	// A failed message stays at the head of its portal's queue until it's
+	// popped, so later messages can't overtake it.
+	require.NotNil(t, q.Peek(portalA))
+	assert.Equal(t, "a1", q.Peek(portalA).TransactionID)
+	assert.Equal(t, "a1", q.Peek(portalA).TransactionID)
+
+	var sent []string
+	for q.HasPending(portalA) {
+		sent = append(sent, q.Peek(portalA).TransactionID)
+		q.Pop(portalA)
+	}
+	assert.Equal(t, []string{"a1", "a2", "a3"}, sent)
+	assert.Nil(t, q.Peek(portalA))
+
+	// Other portals are independent.
+	require.NotNil(t, q.Peek(portalB))
+	assert.Equal(t, "b1", q.Peek(portalB).TransactionID)
+}
+
+func TestOutboundQueuePersistence(t *testing.T) {
+	portal := networkid.PortalKey{ID: "a"}
+	q := connector.NewOutboundQueue()
+	first := &connector.QueuedMessage{
+		PortalKey:     portal,
+		EventID:       "$first",
+		Body:          linkedingo.SendMessageBody{Text: "first"},
+		TransactionID: "txn1",
+		Attempts:      2,
+		LastError:     "unexpected status code 502",
+	}
+	q.Push(first)
+	q.Push(&connector.QueuedMessage{PortalKey: portal, EventID: "$second", TransactionID: "txn2"})
+
+	snapshot := q.Snapshot()
+	require.Len(t, snapshot, 2)
+
+	// The snapshot is a copy, so the worker updating the queued message
+	// doesn't change the stored metadata while it's being saved.
+	first.Attempts++
+	assert.Equal(t, 2, snapshot[0].Attempts)
+
+	data, err := json.Marshal(&connector.UserLoginMetadata{OutboundQueue: snapshot})
+	require.NoError(t, err)
+	var meta connector.UserLoginMetadata
+	require.NoError(t, json.Unmarshal(data, &meta))
+	require.Len(t, meta.OutboundQueue, 2)
+	assert.Equal(t, "txn1", meta.OutboundQueue[0].TransactionID)
+	assert.Equal(t, "first", meta.OutboundQueue[0].Body.Text)
+	assert.Equal(t, 2, meta.OutboundQueue[0].Attempts)
+	assert.Equal(t, "unexpected status code 502", meta.OutboundQueue[0].LastError)
+	assert.Equal(t, "txn2", meta.OutboundQueue[1].TransactionID)
+	assert.Equal(t, portal, meta.OutboundQueue[1].PortalKey)
+}
@@ -0,0 +1,124 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+type reactorKey struct {
+	message linkedingo.URN
+	emoji   string
+}
+
+// inferReactors returns the reactors of a reaction summary if they can be
+// determined without asking LinkedIn, which is the case when the user is the
+// only reactor or when the chat is a DM.
+func (l *LinkedInClient) inferReactors(portal *bridgev2.Portal, summary linkedingo.ReactionSummary) ([]bridgev2.EventSender, bool) {
+	self := bridgev2.EventSender{IsFromMe: true, Sender: l.userID, SenderLogin: l.userLogin.ID}
+	if summary.ViewerReacted && summary.Count == 1 {
+		return []bridgev2.EventSender{self}, true
+	} else if portal.RoomType != database.RoomTypeDM || portal.OtherUserID == "" {
+		return nil, false
+	}
+	other := bridgev2.EventSender{Sender: portal.OtherUserID, SenderLogin: networkid.UserLoginID(portal.OtherUserID)}
+	switch {
+	case summary.Count >= 2:
+		return []bridgev2.EventSender{self, other}, true
+	case summary.ViewerReacted:
+		return []bridgev2.EventSender{self}, true
+	default:
+		return []bridgev2.EventSender{other}, true
+	}
+}
+
+// fetchBackfillReactions returns the reactions of the given messages keyed by
+// message URN. Reactors which can't be inferred are fetched from LinkedIn
+// using a bounded number of concurrent requests, and each message/emoji pair
+// is only fetched once.
+func (l *LinkedInClient) fetchBackfillReactions(ctx context.Context, portal *bridgev2.Portal, messages []linkedingo.Message) map[linkedingo.URN][]*bridgev2.BackfillReaction {
+	log := zerolog.Ctx(ctx)
+	reactors := map[reactorKey][]bridgev2.EventSender{}
+	var toFetch []reactorKey
+	for _, msg := range messages {
+		for _, rs := range msg.ReactionSummaries {
+			key := reactorKey{message: msg.EntityURN, emoji: rs.Emoji}
+			if _, ok := reactors[key]; ok {
+				continue
+			} else if inferred, ok := l.inferReactors(portal, rs); ok {
+				reactors[key] = inferred
+			} else if l.main.Config.Backfill.FetchReactors && rs.Count >= l.main.Config.Backfill.ReactorFetchMinCount {
+				reactors[key] = nil
+				toFetch = append(toFetch, key)
+			}
+		}
+	}
+
+	if len(toFetch) > 0 {
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		sema := make(chan struct{}, max(1, l.main.Config.Backfill.ReactorFetchConcurrency))
+		for _, key := range toFetch {
+			wg.Add(1)
+			sema <- struct{}{}
+			go func() {
+				defer func() {
+					<-sema
+					wg.Done()
+				}()
+				resp, err := l.client.GetReactors(ctx, key.message, key.emoji)
+				if err != nil {
+					log.Err(err).
+						Stringer("message_urn", key.message).
+						Str("emoji", key.emoji).
+						Msg("failed to get reactors")
+					return
+				}
+				senders := make([]bridgev2.EventSender, len(resp.Elements))
+				for i, reactor := range resp.Elements {
+					senders[i] = l.makeSender(reactor)
+				}
+				lock.Lock()
+				reactors[key] = senders
+				lock.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+
+	reactions := make(map[linkedingo.URN][]*bridgev2.BackfillReaction, len(messages))
+	for _, msg := range messages {
+		for _, rs := range msg.ReactionSummaries {
+			for _, sender := range reactors[reactorKey{message: msg.EntityURN, emoji: rs.Emoji}] {
+				reactions[msg.EntityURN] = append(reactions[msg.EntityURN], &bridgev2.BackfillReaction{
+					Sender:  sender,
+					EmojiID: networkid.EmojiID(rs.Emoji),
+					Emoji:   rs.Emoji,
+				})
+			}
+		}
+	}
+	return reactions
+}
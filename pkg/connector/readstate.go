@@ -0,0 +1,194 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+func getPortalMetadata(portal *bridgev2.Portal) *PortalMetadata {
+	meta, ok := portal.Metadata.(*PortalMetadata)
+	if !ok || meta == nil {
+		meta = &PortalMetadata{}
+		portal.Metadata = meta
+	}
+	return meta
+}
+
+// updateLastReadAt returns an [bridgev2.ExtraUpdater] which stores when the
+// user last read the conversation.
+func (l *LinkedInClient) updateLastReadAt(lastReadAt jsontime.UnixMilli) bridgev2.ExtraUpdater[*bridgev2.Portal] {
+	return func(ctx context.Context, portal *bridgev2.Portal) bool {
+		meta := getPortalMetadata(portal)
+		if lastReadAt.IsZero() || !lastReadAt.After(meta.LastReadAt[l.userLogin.ID].Time) {
+			return false
+		}
+		if meta.LastReadAt == nil {
+			meta.LastReadAt = map[networkid.UserLoginID]jsontime.UnixMilli{}
+		}
+		meta.LastReadAt[l.userLogin.ID] = lastReadAt
+		return true
+	}
+}
+
+// updateSeenReceipts returns an [bridgev2.ExtraUpdater] which records the
+// latest message seen by each participant so that the receipts can be
+// restored after backfilling.
+func (l *LinkedInClient) updateSeenReceipts(receipts []linkedingo.SeenReceipt) bridgev2.ExtraUpdater[*bridgev2.Portal] {
+	return func(ctx context.Context, portal *bridgev2.Portal) (changed bool) {
+		meta := getPortalMetadata(portal)
+		for _, receipt := range receipts {
+			userID := networkid.UserID(receipt.SeenByParticipant.EntityURN.ID())
+			if existing, ok := meta.SeenReceipts[userID]; ok && !receipt.SeenAt.After(existing.SeenAt.Time) {
+				continue
+			}
+			if meta.SeenReceipts == nil {
+				meta.SeenReceipts = map[networkid.UserID]SeenReceiptMeta{}
+			}
+			meta.SeenReceipts[userID] = SeenReceiptMeta{
+				MessageID: l.messageID(portal.PortalKey, receipt.Message.EntityURN),
+				SeenAt:    receipt.SeenAt,
+			}
+			if userID == l.userID {
+				l.updateLastReadAt(receipt.SeenAt)(ctx, portal)
+			}
+			changed = true
+		}
+		return
+	}
+}
+
+// seenReceiptStoreDelay is how long seen receipts are collected before they
+// are stored, so that a burst of receipts only saves the portal once.
+const seenReceiptStoreDelay = 5 * time.Second
+
+type pendingSeenReceipts struct {
+	receipts []linkedingo.SeenReceipt
+	timer    *time.Timer
+}
+
+// storeSeenReceipts stores the receipts in the portal metadata. Receipts are
+// collected for a short time and then stored in a single update. The metadata
+// is only modified in the portal event loop, so the update is queued as a chat
+// info change.
+func (l *LinkedInClient) storeSeenReceipts(portalKey networkid.PortalKey, receipts ...linkedingo.SeenReceipt) {
+	if len(receipts) == 0 {
+		return
+	}
+	l.pendingSeenReceiptsLock.Lock()
+	defer l.pendingSeenReceiptsLock.Unlock()
+	pending, ok := l.pendingSeenReceipts[portalKey]
+	if !ok {
+		pending = &pendingSeenReceipts{}
+		l.pendingSeenReceipts[portalKey] = pending
+		pending.timer = time.AfterFunc(seenReceiptStoreDelay, func() {
+			l.flushSeenReceipts(portalKey)
+		})
+	}
+	pending.receipts = append(pending.receipts, receipts...)
+}
+
+func (l *LinkedInClient) flushSeenReceipts(portalKey networkid.PortalKey) {
+	l.pendingSeenReceiptsLock.Lock()
+	pending, ok := l.pendingSeenReceipts[portalKey]
+	delete(l.pendingSeenReceipts, portalKey)
+	l.pendingSeenReceiptsLock.Unlock()
+	if !ok {
+		return
+	}
+	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.ChatInfoChange{
+		EventMeta: simplevent.EventMeta{
+			Type: bridgev2.RemoteEventChatInfoChange,
+			LogContext: func(c zerolog.Context) zerolog.Context {
+				return c.
+					Str("update", "store_seen_receipts").
+					Int("receipt_count", len(pending.receipts))
+			},
+			PortalKey: portalKey,
+			Sender:    bridgev2.EventSender{IsFromMe: true, Sender: l.userID, SenderLogin: l.userLogin.ID},
+		},
+		ChatInfoChange: &bridgev2.ChatInfoChange{
+			ChatInfo: &bridgev2.ChatInfo{ExtraUpdates: l.updateSeenReceipts(pending.receipts)},
+		},
+	})
+}
+
+// flushAllSeenReceipts immediately stores all collected seen receipts.
+func (l *LinkedInClient) flushAllSeenReceipts() {
+	l.pendingSeenReceiptsLock.Lock()
+	portalKeys := make([]networkid.PortalKey, 0, len(l.pendingSeenReceipts))
+	for portalKey, pending := range l.pendingSeenReceipts {
+		pending.timer.Stop()
+		portalKeys = append(portalKeys, portalKey)
+	}
+	l.pendingSeenReceiptsLock.Unlock()
+	for _, portalKey := range portalKeys {
+		l.flushSeenReceipts(portalKey)
+	}
+}
+
+// queueBackfillReceipts sends read receipts for the user and the other
+// participants which point at the newest backfilled message they have seen.
+func (l *LinkedInClient) queueBackfillReceipts(portal *bridgev2.Portal, messages []*bridgev2.BackfillMessage) {
+	meta := getPortalMetadata(portal)
+	readUpTo := map[bridgev2.EventSender]time.Time{}
+	if lastReadAt := meta.LastReadAt[l.userLogin.ID]; !lastReadAt.IsZero() {
+		readUpTo[bridgev2.EventSender{IsFromMe: true, Sender: l.userID, SenderLogin: l.userLogin.ID}] = lastReadAt.Time
+	}
+	for userID, receipt := range meta.SeenReceipts {
+		if userID == l.userID {
+			continue
+		}
+		readUpTo[bridgev2.EventSender{Sender: userID, SenderLogin: networkid.UserLoginID(userID)}] = receipt.SeenAt.Time
+	}
+
+	for sender, seenAt := range readUpTo {
+		var target *bridgev2.BackfillMessage
+		for _, msg := range messages {
+			if msg.Timestamp.After(seenAt) {
+				break
+			}
+			target = msg
+		}
+		if target == nil {
+			continue
+		}
+		l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Receipt{
+			EventMeta: simplevent.EventMeta{
+				Type: bridgev2.RemoteEventReadReceipt,
+				LogContext: func(c zerolog.Context) zerolog.Context {
+					return c.
+						Str("update", "backfill_receipt").
+						Time("seen_at", seenAt)
+				},
+				PortalKey: portal.PortalKey,
+				Sender:    sender,
+				Timestamp: seenAt,
+			},
+			LastTarget: target.ID,
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/exsync"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+const (
+	// realtimeDedupSize is the number of recent decorated event IDs that are
+	// remembered to drop events that LinkedIn redelivers after a reconnect.
+	realtimeDedupSize = 1024
+	// realtimeReorderWindow is how long messages are held per conversation
+	// before being handled in DeliveredAt order.
+	realtimeReorderWindow = 750 * time.Millisecond
+
+	deferredRetryAttempts = 5
+)
+
+var deferredRetryInterval = 2 * time.Second
+
+// realtimeEventDedup remembers the IDs of recent decorated events.
+type realtimeEventDedup struct {
+	recent *exsync.RingBuffer[string, struct{}]
+}
+
+func newRealtimeEventDedup(size int) *realtimeEventDedup {
+	return &realtimeEventDedup{recent: exsync.NewRingBuffer[string, struct{}](size)}
+}
+
+// Seen returns true if the event ID was already seen, and remembers it
+// otherwise.
+func (d *realtimeEventDedup) Seen(eventID string) bool {
+	if d.recent.Contains(eventID) {
+		return true
+	}
+	d.recent.Push(eventID, struct{}{})
+	return false
+}
+
+type bufferedMessage struct {
+	ctx context.Context
+	msg linkedingo.Message
+}
+
+type conversationReorderBuffer struct {
+	messages []bufferedMessage
+	timer    *time.Timer
+}
+
+// realtimeReorderBuffer holds realtime messages for a short time so that
+// messages which arrive out of order are handled in the order they were
+// delivered.
+type realtimeReorderBuffer struct {
+	lock    sync.Mutex
+	pending map[linkedingo.URN]*conversationReorderBuffer
+	window  time.Duration
+	handle  func(ctx context.Context, msg linkedingo.Message)
+}
+
+func newRealtimeReorderBuffer(window time.Duration, handle func(ctx context.Context, msg linkedingo.Message)) *realtimeReorderBuffer {
+	return &realtimeReorderBuffer{
+		pending: map[linkedingo.URN]*conversationReorderBuffer{},
+		window:  window,
+		handle:  handle,
+	}
+}
+
+func (rb *realtimeReorderBuffer) Add(ctx context.Context, msg linkedingo.Message) {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+	convURN := msg.Conversation.EntityURN
+	buf, ok := rb.pending[convURN]
+	if !ok {
+		buf = &conversationReorderBuffer{}
+		rb.pending[convURN] = buf
+		buf.timer = time.AfterFunc(rb.window, func() {
+			rb.flush(convURN)
+		})
+	}
+	buf.messages = append(buf.messages, bufferedMessage{ctx: ctx, msg: msg})
+}
+
+func (rb *realtimeReorderBuffer) flush(convURN linkedingo.URN) {
+	rb.lock.Lock()
+	buf, ok := rb.pending[convURN]
+	delete(rb.pending, convURN)
+	rb.lock.Unlock()
+	if !ok {
+		return
+	}
+	slices.SortStableFunc(buf.messages, func(a, b bufferedMessage) int {
+		return a.msg.DeliveredAt.Compare(b.msg.DeliveredAt.Time)
+	})
+	for _, bm := range buf.messages {
+		rb.handle(bm.ctx, bm.msg)
+	}
+}
+
+// FlushAll immediately handles all buffered messages.
+func (rb *realtimeReorderBuffer) FlushAll() {
+	rb.lock.Lock()
+	convURNs := make([]linkedingo.URN, 0, len(rb.pending))
+	for convURN, buf := range rb.pending {
+		buf.timer.Stop()
+		convURNs = append(convURNs, convURN)
+	}
+	rb.lock.Unlock()
+	for _, convURN := range convURNs {
+		rb.flush(convURN)
+	}
+}
+
+// retryDeferred calls fn until it returns true or the attempts run out. It is
+// used for realtime events whose target message isn't in the database yet.
+func retryDeferred(ctx context.Context, what string, fn func(ctx context.Context) bool) {
+	if fn(ctx) {
+		return
+	}
+	interval := deferredRetryInterval
+	go func() {
+		log := zerolog.Ctx(ctx).With().Str("deferred", what).Logger()
+		for attempt := 1; attempt <= deferredRetryAttempts; attempt++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			log.Debug().Int("attempt", attempt).Msg("Retrying deferred event")
+			if fn(ctx) {
+				return
+			}
+		}
+		log.Warn().Msg("Target message still not found, dropping deferred event")
+	}()
+}
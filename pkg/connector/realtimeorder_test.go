@@ -0,0 +1,162 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/util/jsontime"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+func TestRealtimeEventDedup(t *testing.T) {
+	dedup := connector.NewRealtimeEventDedup(4)
+	assert.False(t, dedup.Seen("a"))
+	assert.True(t, dedup.Seen("a"))
+	assert.False(t, dedup.Seen("b"))
+	assert.True(t, dedup.Seen("a"))
+	assert.True(t, dedup.Seen("b"))
+
+	// Old IDs are forgotten once enough newer events have been seen.
+	for _, eventID := range []string{"c", "d", "e", "f"} {
+		assert.False(t, dedup.Seen(eventID))
+	}
+	assert.False(t, dedup.Seen("a"))
+}
+
+func makeRealtimeMessage(convID, msgID string, deliveredAt int64) linkedingo.Message {
+	return linkedingo.Message{
+		EntityURN:    linkedingo.NewURN("urn:li:msg_message:" + msgID),
+		DeliveredAt:  jsontime.UM(time.UnixMilli(deliveredAt)),
+		Conversation: linkedingo.Conversation{EntityURN: linkedingo.NewURN("urn:li:msg_conversation:" + convID)},
+	}
+}
+
+type handledMessages struct {
+	lock sync.Mutex
+	ids  []string
+}
+
+func (hm *handledMessages) handle(ctx context.Context, msg linkedingo.Message) {
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+	hm.ids = append(hm.ids, msg.EntityURN.ID())
+}
+
+func (hm *handledMessages) get() []string {
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+	return hm.ids
+}
+
+func TestRealtimeReorderBufferFlushAll(t *testing.T) {
+	var handled handledMessages
+	// The window is long enough that only FlushAll handles the messages.
+	buf := connector.NewRealtimeReorderBuffer(time.Hour, handled.handle)
+	buf.Add(context.TODO(), makeRealtimeMessage("conv", "3", 3000))
+	buf.Add(context.TODO(), makeRealtimeMessage("conv", "1", 1000))
+	buf.Add(context.TODO(), makeRealtimeMessage("conv", "2", 2000))
+	assert.Empty(t, handled.get())
+
+	buf.FlushAll()
+	assert.Equal(t, []string{"1", "2", "3"}, handled.get())
+
+	// Flushing again doesn't handle anything twice.
+	buf.FlushAll()
+	assert.Equal(t, []string{"1", "2", "3"}, handled.get())
+}
+
+func TestRealtimeReorderBufferStableOrder(t *testing.T) {
+	var handled handledMessages
+	buf := connector.NewRealtimeReorderBuffer(time.Hour, handled.handle)
+	buf.Add(context.TODO(), makeRealtimeMessage("conv", "b", 1000))
+	buf.Add(context.TODO(), makeRealtimeMessage("conv", "a", 1000))
+	buf.FlushAll()
+	assert.Equal(t, []string{"b", "a"}, handled.get())
+}
+
+func TestRealtimeReorderBufferWindow(t *testing.T) {
+	done := make(chan string, 4)
+	buf := connector.NewRealtimeReorderBuffer(10*time.Millisecond, func(ctx context.Context, msg linkedingo.Message) {
+		done <- msg.EntityURN.ID()
+	})
+	buf.Add(context.TODO(), makeRealtimeMessage("conv1", "2", 2000))
+	buf.Add(context.TODO(), makeRealtimeMessage("conv1", "1", 1000))
+	buf.Add(context.TODO(), makeRealtimeMessage("conv2", "x", 500))
+
+	var conv1 []string
+	for range 3 {
+		select {
+		case id := <-done:
+			if id != "x" {
+				conv1 = append(conv1, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for buffered messages")
+		}
+	}
+	assert.Equal(t, []string{"1", "2"}, conv1)
+}
+
+func TestRetryDeferred(t *testing.T) {
+	defer connector.SetDeferredRetryInterval(time.Millisecond)()
+
+	t.Run("immediate", func(t *testing.T) {
+		calls := 0
+		connector.RetryDeferred(context.TODO(), "test", func(ctx context.Context) bool {
+			calls++
+			return true
+		})
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("eventual", func(t *testing.T) {
+		attempts := make(chan int, 10)
+		calls := 0
+		connector.RetryDeferred(context.TODO(), "test", func(ctx context.Context) bool {
+			calls++
+			attempts <- calls
+			return calls == 3
+		})
+		var last int
+		for last != 3 {
+			select {
+			case last = <-attempts:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for retries")
+			}
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		calls := make(chan struct{}, 10)
+		connector.RetryDeferred(ctx, "test", func(ctx context.Context) bool {
+			calls <- struct{}{}
+			return false
+		})
+		require.Len(t, calls, 1)
+	})
+}
@@ -0,0 +1,84 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// EventReplySuggestions is sent by the bridge bot when LinkedIn suggests quick
+// replies for a message. It references the message the suggestions are for.
+var EventReplySuggestions = event.Type{Type: "fi.mau.linkedin.reply_suggestions", Class: event.MessageEventType}
+
+type ReplySuggestion struct {
+	Body string `json:"body"`
+}
+
+type ReplySuggestionsEventContent struct {
+	Suggestions []ReplySuggestion `json:"suggestions"`
+	RelatesTo   *event.RelatesTo  `json:"m.relates_to,omitempty"`
+}
+
+func (l *LinkedInClient) onRealtimeReplySuggestions(ctx context.Context, _ *linkedingo.DecoratedEvent, recommendation *linkedingo.RealtimeQuickReplyRecommendation) {
+	if recommendation == nil || !l.main.Config.ReplySuggestions {
+		return
+	}
+	texts := recommendation.Texts()
+	if len(texts) == 0 {
+		return
+	}
+	retryDeferred(ctx, "reply_suggestions", func(ctx context.Context) bool {
+		return l.handleReplySuggestions(ctx, recommendation.Message, texts)
+	})
+}
+
+func (l *LinkedInClient) handleReplySuggestions(ctx context.Context, msg linkedingo.Message, texts []string) bool {
+	log := zerolog.Ctx(ctx).With().Stringer("message_urn", msg.EntityURN).Logger()
+	part, err := l.getMessagePartByURN(ctx, msg.EntityURN, l.main.Bridge.DB.Message.GetLastPartByID)
+	if err != nil {
+		log.Err(err).Msg("Failed to get message for reply suggestions")
+		return true
+	} else if part == nil {
+		log.Debug().Msg("Couldn't find message for reply suggestions")
+		return false
+	}
+	portal, err := l.main.Bridge.GetExistingPortalByKey(ctx, part.Room)
+	if err != nil {
+		log.Err(err).Msg("Failed to get portal for reply suggestions")
+		return true
+	} else if portal == nil || portal.MXID == "" {
+		return true
+	}
+
+	content := &ReplySuggestionsEventContent{
+		Suggestions: make([]ReplySuggestion, len(texts)),
+		RelatesTo:   &event.RelatesTo{Type: event.RelReference, EventID: part.MXID},
+	}
+	for i, text := range texts {
+		content.Suggestions[i] = ReplySuggestion{Body: text}
+	}
+	_, err = l.main.Bridge.Bot.SendMessage(ctx, portal.MXID, EventReplySuggestions, &event.Content{Parsed: content}, nil)
+	if err != nil {
+		log.Err(err).Msg("Failed to send reply suggestions")
+	}
+	return true
+}
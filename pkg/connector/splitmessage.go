@@ -0,0 +1,127 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/matrixfmt"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+var ErrEditTooLong = bridgev2.WrapErrorInStatus(errors.New("edited message doesn't fit in the messages it was originally split into")).
+	WithStatus(event.MessageStatusFail).WithIsCertain(true).WithSendNotice(true).WithErrorAsMessage()
+
+// splitPartTransactionID returns the origin token of an additional part of a
+// split message. It's derived from the transaction ID of the first part, so
+// that LinkedIn deduplicates the parts when a split message is retried.
+func splitPartTransactionID(transactionID string, part int) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, fmt.Appendf(nil, "%s/%d", transactionID, part)).String()
+}
+
+// sendMessageParts sends a message to LinkedIn, splitting the body into
+// multiple messages if it's longer than [MaxTextLength]. Attachments and the
+// reply are only included in the first message. The additional messages are
+// returned as database rows to save with [LinkedInClient.saveSplitParts].
+func (l *LinkedInClient) sendMessageParts(
+	ctx context.Context,
+	msg *bridgev2.MatrixMessage,
+	conversationURN linkedingo.URN,
+	body linkedingo.SendMessageBody,
+	renderContent []linkedingo.SendRenderContent,
+	transactionID string,
+) (resp *linkedingo.MessageSentResponse, parts []*database.Message, err error) {
+	chunks := matrixfmt.Split(body, MaxTextLength)
+	for i := 1; i < len(chunks); i++ {
+		// The additional parts don't have Matrix events of their own, so
+		// their echoes must not be bridged.
+		msg.AddPendingToIgnore(networkid.TransactionID(splitPartTransactionID(transactionID, i)))
+	}
+	resp, err = l.client.SendMessage(ctx, conversationURN, chunks[0], renderContent, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := 1; i < len(chunks); i++ {
+		partResp, err := l.client.SendMessage(ctx, conversationURN, chunks[i], nil, splitPartTransactionID(transactionID, i))
+		if err != nil {
+			return resp, parts, fmt.Errorf("failed to send part %d of %d: %w", i+1, len(chunks), err)
+		}
+		parts = append(parts, &database.Message{
+			ID:        l.messageID(msg.Portal.PortalKey, partResp.Data.EntityURN),
+			Room:      msg.Portal.PortalKey,
+			SenderID:  l.userID,
+			Timestamp: partResp.Data.DeliveredAt.Time,
+		})
+	}
+	if len(parts) > 0 {
+		zerolog.Ctx(ctx).Debug().Int("part_count", len(chunks)).Msg("Sent long message in multiple parts")
+	}
+	return resp, parts, nil
+}
+
+// recallMessageParts recalls the parts of a split message which were sent
+// before sending a later part failed.
+func (l *LinkedInClient) recallMessageParts(ctx context.Context, resp *linkedingo.MessageSentResponse, parts []*database.Message) {
+	if resp == nil {
+		return
+	}
+	urns := []linkedingo.URN{resp.Data.EntityURN}
+	for _, part := range parts {
+		urns = append(urns, l.messageURN(part.ID))
+	}
+	for _, urn := range urns {
+		if err := l.client.RecallMessage(ctx, urn); err != nil {
+			zerolog.Ctx(ctx).Err(err).Stringer("message_urn", urn).Msg("Failed to recall part of partially sent message")
+		}
+	}
+}
+
+// saveSplitParts saves the additional parts of a split message. The parts
+// don't have Matrix events of their own, so they're stored with fake event
+// IDs, which makes the bridge ignore their echoes and backfilled copies.
+func (l *LinkedInClient) saveSplitParts(ctx context.Context, sender id.UserID, parts []*database.Message) []networkid.MessageID {
+	ids := make([]networkid.MessageID, len(parts))
+	for i, part := range parts {
+		ids[i] = part.ID
+		part.SenderMXID = sender
+		part.SetFakeMXID()
+		if err := l.main.Bridge.DB.Message.Insert(ctx, part); err != nil {
+			zerolog.Ctx(ctx).Err(err).Str("message_id", string(part.ID)).Msg("Failed to save part of split message")
+		}
+	}
+	return ids
+}
+
+// splitMessageIDs returns the IDs of all LinkedIn messages which the given
+// Matrix message was sent as.
+func splitMessageIDs(msg *database.Message) []networkid.MessageID {
+	ids := []networkid.MessageID{msg.ID}
+	if meta, ok := msg.Metadata.(*MessageMetadata); ok {
+		ids = append(ids, meta.SplitParts...)
+	}
+	return ids
+}
@@ -2,9 +2,11 @@ package connector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/rs/zerolog"
 	"go.mau.fi/util/ptr"
 	"maunium.net/go/mautrix/bridgev2"
 	"maunium.net/go/mautrix/bridgev2/database"
@@ -73,9 +75,82 @@ func (l *LinkedInClient) CreateChatWithGhost(ctx context.Context, ghost *bridgev
 	} else if resp == nil {
 		return nil, nil
 	}
+	if resp.Chat != nil && resp.Chat.PortalInfo != nil {
+		resp.Chat.PortalInfo.ExtraUpdates = bridgev2.MergeExtraUpdaters(resp.Chat.PortalInfo.ExtraUpdates, l.markCreatedByInvite)
+	}
 	return resp.Chat, nil
 }
 
+// markCreatedByInvite marks DMs which were started by inviting a ghost to a
+// room the user created. CreateChatWithGhost is also used by provisioning,
+// which creates the room itself, so the flag is only set if the chat info is
+// applied to an existing room which wasn't created by the bridge bot.
+func (l *LinkedInClient) markCreatedByInvite(ctx context.Context, portal *bridgev2.Portal) bool {
+	meta := getPortalMetadata(portal)
+	if meta.CreatedByInvite || portal.MXID == "" {
+		return false
+	}
+	stateConn, ok := l.main.Bridge.Matrix.(bridgev2.MatrixConnectorWithArbitraryRoomState)
+	if !ok {
+		return false
+	}
+	createEvt, err := stateConn.GetStateEvent(ctx, portal.MXID, event.StateCreate, "")
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get create event of DM room")
+		return false
+	} else if createEvt.Sender == l.main.Bridge.Bot.GetMXID() {
+		return false
+	}
+	meta.CreatedByInvite = true
+	return true
+}
+
+// upgradeDMToGroup creates a group with the other user of a DM and the
+// invited ghost, and moves the DM room over to the new group.
+func (l *LinkedInClient) upgradeDMToGroup(ctx context.Context, dmPortal *bridgev2.Portal, invited *bridgev2.Ghost) error {
+	if dmPortal.OtherUserID == "" {
+		return errors.New("couldn't find the other user of the DM")
+	}
+	chatInfo := &bridgev2.ChatInfo{
+		Type: ptr.Ptr(database.RoomTypeDefault),
+		Members: &bridgev2.ChatMemberList{
+			MemberMap: map[networkid.UserID]bridgev2.ChatMember{},
+		},
+	}
+	chat, err := l.createChat(ctx, chatInfo, []networkid.UserID{dmPortal.OtherUserID, invited.ID})
+	if err != nil {
+		return fmt.Errorf("failed to create group chat: %w", err)
+	}
+	groupPortal, err := l.main.Bridge.GetPortalByKey(ctx, chat.PortalKey)
+	if err != nil {
+		return fmt.Errorf("failed to get group portal: %w", err)
+	}
+	zerolog.Ctx(ctx).Info().
+		Object("group_portal_key", groupPortal.PortalKey).
+		Msg("Moving DM room to new group chat")
+	err = groupPortal.UpdateMatrixRoomID(ctx, dmPortal.MXID, bridgev2.UpdateMatrixRoomIDParams{
+		FailIfMXIDSet:      true,
+		OverwriteOldPortal: true,
+		ChatInfo:           chat.PortalInfo,
+		ChatInfoSource:     l.userLogin,
+	})
+	if errors.Is(err, bridgev2.ErrRoomAlreadyExists) {
+		// The realtime event for the new conversation was handled before
+		// LinkedIn responded, so the group already got a room of its own.
+		// Move the DM room over anyway and point the other room to it.
+		zerolog.Ctx(ctx).Info().
+			Stringer("group_room_id", groupPortal.MXID).
+			Msg("Group chat already has a room, replacing it with the DM room")
+		err = groupPortal.UpdateMatrixRoomID(ctx, dmPortal.MXID, bridgev2.UpdateMatrixRoomIDParams{
+			OverwriteOldPortal: true,
+			TombstoneOldRoom:   true,
+			ChatInfo:           chat.PortalInfo,
+			ChatInfoSource:     l.userLogin,
+		})
+	}
+	return err
+}
+
 func (l *LinkedInClient) CreateGroup(ctx context.Context, params *bridgev2.GroupCreateParams) (*bridgev2.CreateChatResponse, error) {
 	chatInfo := &bridgev2.ChatInfo{
 		Type: ptr.Ptr(database.RoomTypeDefault),
@@ -109,10 +184,10 @@ func (l *LinkedInClient) createChat(ctx context.Context, chatInfo *bridgev2.Chat
 		return nil, err
 	}
 
-	portalKey := networkid.PortalKey{
-		ID:       networkid.PortalID(resp.Data.ConversationURN.String()),
-		Receiver: l.userLogin.ID,
-	}
+	portalKey := l.makePortalKey(linkedingo.Conversation{
+		EntityURN: resp.Data.ConversationURN,
+		GroupChat: len(participants) > 1,
+	})
 	return &bridgev2.CreateChatResponse{
 		PortalKey:  portalKey,
 		PortalInfo: chatInfo,
@@ -0,0 +1,173 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-linkedin/pkg/connector/linkedinfmt"
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+// systemMessageTemplate replaces the mentioned entities in the body of a
+// system message with placeholders, so "Jane Doe added John Smith" becomes
+// "{} added {}". The entity URNs are returned in order of appearance.
+func systemMessageTemplate(body linkedingo.AttributedText) (string, []linkedingo.URN) {
+	entities := slices.DeleteFunc(slices.Clone(body.Attributes), func(attr linkedingo.Attribute) bool {
+		return attr.AttributeKind.Entity == nil
+	})
+	slices.SortFunc(entities, func(a, b linkedingo.Attribute) int {
+		return a.Start - b.Start
+	})
+	text := []rune(body.Text)
+	var template strings.Builder
+	var urns []linkedingo.URN
+	var pos int
+	for _, entity := range entities {
+		if entity.Start < pos || entity.Start+entity.Length > len(text) {
+			continue
+		}
+		template.WriteString(string(text[pos:entity.Start]))
+		template.WriteString("{}")
+		urns = append(urns, entity.AttributeKind.Entity.URN)
+		pos = entity.Start + entity.Length
+	}
+	template.WriteString(string(text[pos:]))
+	return strings.ToLower(strings.TrimSpace(template.String())), urns
+}
+
+func quotedText(text string) string {
+	for _, quotes := range [][2]string{{"“", "”"}, {`"`, `"`}} {
+		start := strings.Index(text, quotes[0])
+		if start < 0 {
+			continue
+		}
+		rest := text[start+len(quotes[0]):]
+		if end := strings.LastIndex(rest, quotes[1]); end >= 0 {
+			return rest[:end]
+		}
+	}
+	return ""
+}
+
+// parseSystemMessage converts system messages about membership changes and
+// renames into a chat info change. It returns nil for other system messages.
+//
+// The text of system messages is localized, and the templates below only
+// match English. For other languages, leaves are derived from the participant
+// list instead (see [LinkedInClient.parseSystemMessageMembers]), without who
+// made the change. Other system messages in other languages, including adds
+// and renames, are bridged as notices, and the next chat sync updates the
+// members and name.
+func (l *LinkedInClient) parseSystemMessage(msg linkedingo.Message) *bridgev2.ChatInfoChange {
+	template, urns := systemMessageTemplate(msg.Body)
+	member := func(urn linkedingo.URN, membership event.Membership, memberSender bridgev2.EventSender) bridgev2.ChatMember {
+		sender := l.makeSender(linkedingo.MessagingParticipant{EntityURN: urn})
+		return bridgev2.ChatMember{EventSender: sender, Membership: membership, MemberSender: memberSender}
+	}
+	switch {
+	case strings.Contains(template, "renamed the conversation") || strings.Contains(template, "named the conversation"):
+		name := quotedText(msg.Body.Text)
+		if name == "" {
+			name = msg.Conversation.Title
+		}
+		if name == "" {
+			return nil
+		}
+		return &bridgev2.ChatInfoChange{ChatInfo: &bridgev2.ChatInfo{Name: &name}}
+	case len(urns) >= 2 && strings.HasPrefix(template, "{} added {}"):
+		changes := &bridgev2.ChatMemberList{MemberMap: map[networkid.UserID]bridgev2.ChatMember{}}
+		actor := l.makeSender(linkedingo.MessagingParticipant{EntityURN: urns[0]})
+		for _, urn := range urns[1:] {
+			changes.MemberMap.Set(member(urn, event.MembershipJoin, actor))
+		}
+		return &bridgev2.ChatInfoChange{MemberChanges: changes}
+	case len(urns) >= 2 && strings.HasPrefix(template, "{} removed {}"):
+		changes := &bridgev2.ChatMemberList{MemberMap: map[networkid.UserID]bridgev2.ChatMember{}}
+		actor := l.makeSender(linkedingo.MessagingParticipant{EntityURN: urns[0]})
+		for _, urn := range urns[1:] {
+			changes.MemberMap.Set(member(urn, event.MembershipLeave, actor))
+		}
+		return &bridgev2.ChatInfoChange{MemberChanges: changes}
+	case len(urns) == 0 && strings.HasPrefix(template, "you left"):
+		changes := &bridgev2.ChatMemberList{MemberMap: map[networkid.UserID]bridgev2.ChatMember{}}
+		changes.MemberMap.Set(member(linkedingo.NewURN(l.userID), event.MembershipLeave, bridgev2.EventSender{}))
+		return &bridgev2.ChatInfoChange{MemberChanges: changes}
+	case len(urns) == 1 && strings.HasPrefix(template, "{} left"):
+		changes := &bridgev2.ChatMemberList{MemberMap: map[networkid.UserID]bridgev2.ChatMember{}}
+		changes.MemberMap.Set(member(urns[0], event.MembershipLeave, bridgev2.EventSender{}))
+		return &bridgev2.ChatInfoChange{MemberChanges: changes}
+	default:
+		return l.parseSystemMessageMembers(msg, urns)
+	}
+}
+
+// parseSystemMessageMembers derives leaves from a system message without
+// relying on its text. The participants mentioned in the message who aren't in
+// the conversation anymore have left or were removed. If nobody is mentioned
+// and the user isn't in the conversation anymore, the user left. Joins aren't
+// derived, as mentioning a participant doesn't mean they were added, and new
+// participants are synced from the conversation anyway. It returns nil if the
+// message doesn't include the participant list or nobody left.
+func (l *LinkedInClient) parseSystemMessageMembers(msg linkedingo.Message, urns []linkedingo.URN) *bridgev2.ChatInfoChange {
+	participants := msg.Conversation.ConversationParticipants
+	if len(participants) == 0 {
+		return nil
+	}
+	isParticipant := func(urn linkedingo.URN) bool {
+		return slices.ContainsFunc(participants, func(participant linkedingo.MessagingParticipant) bool {
+			return participant.EntityURN.ID() == urn.ID()
+		})
+	}
+	self := linkedingo.NewURN(l.userID)
+	if len(urns) == 0 && !isParticipant(self) {
+		urns = []linkedingo.URN{self}
+	}
+	changes := &bridgev2.ChatMemberList{MemberMap: map[networkid.UserID]bridgev2.ChatMember{}}
+	for _, urn := range urns {
+		if isParticipant(urn) {
+			continue
+		}
+		changes.MemberMap.Set(bridgev2.ChatMember{
+			EventSender: l.makeSender(linkedingo.MessagingParticipant{EntityURN: urn}),
+			Membership:  event.MembershipLeave,
+		})
+	}
+	if len(changes.MemberMap) == 0 {
+		return nil
+	}
+	return &bridgev2.ChatInfoChange{MemberChanges: changes}
+}
+
+// convertSystemMessageToMatrix converts a system message into a notice, which
+// is sent by the bridge bot.
+func (l *LinkedInClient) convertSystemMessageToMatrix(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, msg linkedingo.Message) (*bridgev2.ConvertedMessage, error) {
+	content, err := linkedinfmt.Parse(ctx, msg.Body.Text, msg.Body.Attributes, l.linkedinFmtParams)
+	if err != nil {
+		return nil, err
+	}
+	content.MsgType = event.MsgNotice
+	return &bridgev2.ConvertedMessage{
+		Parts: []*bridgev2.ConvertedMessagePart{{Type: event.EventMessage, Content: content}},
+	}, nil
+}
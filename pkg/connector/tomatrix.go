@@ -53,7 +53,7 @@ func (l *LinkedInClient) convertToMatrix(ctx context.Context, portal *bridgev2.P
 		cm.Parts = []*bridgev2.ConvertedMessagePart{textPart}
 	}
 
-	ctx = context.WithValue(ctx, contextKeyMsgID, msg.MessageID())
+	ctx = context.WithValue(ctx, contextKeyMsgID, l.messageID(portal.PortalKey, msg.EntityURN))
 	for i, rc := range msg.RenderContent {
 		partID := networkid.PartID(fmt.Sprintf("part_%d", i))
 		ctx := context.WithValue(ctx, contextKeyPartID, partID)
@@ -96,7 +96,7 @@ func (l *LinkedInClient) convertToMatrix(ctx context.Context, portal *bridgev2.P
 			part, err = l.convertHostURNToMatrix(ctx, portal, intent, rc.HostURNData, textPart)
 		case rc.RepliedMessageContent != nil:
 			cm.ReplyTo = &networkid.MessageOptionalPartID{
-				MessageID: rc.RepliedMessageContent.OriginalMessage.MessageID(),
+				MessageID: l.messageID(portal.PortalKey, rc.RepliedMessageContent.OriginalMessage.EntityURN),
 			}
 		case rc.VectorImage != nil:
 			part, err = l.convertVectorImageToMatrix(ctx, portal, intent, rc.VectorImage)
@@ -124,22 +124,53 @@ func (l *LinkedInClient) convertToMatrix(ctx context.Context, portal *bridgev2.P
 	return &cm, nil
 }
 
-func (l *LinkedInClient) convertEditToMatrix(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, existing []*database.Message, msg linkedingo.Message) (*bridgev2.ConvertedEdit, error) {
-	if len(existing) != 1 {
-		return nil, fmt.Errorf("editing a message that was bridged as multiple parts is not supported")
+// convertBackfilledEdit converts the edit of a backfilled message. Messages
+// which have already been edited in Matrix are left alone, as the backfilled
+// content would be the same as the last edit.
+func (l *LinkedInClient) convertBackfilledEdit(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, existing []*database.Message, msg linkedingo.Message) (*bridgev2.ConvertedEdit, error) {
+	if existing[0].EditCount > 0 {
+		return nil, bridgev2.ErrIgnoringRemoteEvent
 	}
+	return l.convertEditToMatrix(ctx, portal, intent, existing, msg)
+}
+
+func (l *LinkedInClient) convertEditToMatrix(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, existing []*database.Message, msg linkedingo.Message) (*bridgev2.ConvertedEdit, error) {
 	converted, err := l.convertToMatrix(ctx, portal, intent, msg)
 	if err != nil {
 		return nil, err
 	}
-	if len(converted.Parts) != 1 {
-		return nil, fmt.Errorf("editing a message in a way that creates multiple parts is not supported")
+	if len(existing) == 1 && len(converted.Parts) == 1 {
+		// The caption is merged into the media part, so the part ID changes
+		// when a caption is added or removed.
+		return &bridgev2.ConvertedEdit{
+			ModifiedParts: []*bridgev2.ConvertedEditPart{converted.Parts[0].ToEditPart(existing[0])},
+		}, nil
 	}
 
+	// Only the text of a message can be edited on LinkedIn, so the
+	// attachments are left as they are.
+	var textPart *bridgev2.ConvertedMessagePart
+	for _, part := range converted.Parts {
+		if part.ID == "" {
+			textPart = part
+			break
+		}
+	}
+	var existingTextPart *database.Message
+	for _, part := range existing {
+		if part.PartID == "" {
+			existingTextPart = part
+			break
+		}
+	}
 	var convertedEdit bridgev2.ConvertedEdit
-	for i, part := range converted.Parts {
-		convertedEdit.ModifiedParts = append(convertedEdit.ModifiedParts, part.ToEditPart(existing[i]))
+	if textPart != nil && existingTextPart != nil {
+		convertedEdit.ModifiedParts = append(convertedEdit.ModifiedParts, textPart.ToEditPart(existingTextPart))
+	} else if existingTextPart != nil {
+		convertedEdit.DeletedParts = append(convertedEdit.DeletedParts, existingTextPart)
 	}
+	// If there's no existing text part, the caption was sent from Matrix as
+	// part of one of the images, which already has the edited caption.
 	return &convertedEdit, nil
 }
 
@@ -149,7 +180,13 @@ func (l *LinkedInClient) convertToDirectMedia(ctx context.Context, portal *bridg
 	if ctx.Value(contextKeyPartID) != nil {
 		partID = ctx.Value(contextKeyPartID).(networkid.PartID)
 	}
-	mediaID := MakeMediaID(portal.Receiver, msgID, partID)
+	// Shared portals don't have a receiver, so the media is downloaded using
+	// the login which bridged the message.
+	receiver := portal.Receiver
+	if receiver == "" {
+		receiver = l.userLogin.ID
+	}
+	mediaID := MakeMediaID(receiver, msgID, partID)
 	var err error
 	content.URL, err = l.main.Bridge.Matrix.GenerateContentURI(ctx, mediaID)
 	if err != nil {
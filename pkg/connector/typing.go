@@ -0,0 +1,191 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package connector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+const (
+	// linkedInTypingTimeout is how long a LinkedIn typing indicator is shown
+	// if it isn't repeated.
+	linkedInTypingTimeout = 10 * time.Second
+	// typingRefreshInterval is how often the typing indicator is re-sent to
+	// LinkedIn while the user is typing on Matrix.
+	typingRefreshInterval = 7 * time.Second
+	// maxTypingDuration caps how long typing is refreshed in case Matrix never
+	// sends a typing stop.
+	maxTypingDuration = 2 * time.Minute
+)
+
+type typingKey struct {
+	portal networkid.PortalKey
+	sender networkid.UserID
+}
+
+// typingTracker keeps track of the typing state in both directions.
+type typingTracker struct {
+	lock sync.Mutex
+	// remote is when each LinkedIn user's typing indicator expires.
+	remote map[typingKey]time.Time
+	// local is the refresh loop of each portal the user is typing in.
+	local map[networkid.PortalKey]*localTyping
+}
+
+// localTyping is a typing refresh loop. It's compared by pointer, so that an
+// ending loop doesn't remove a newer loop of the same portal.
+type localTyping struct {
+	cancel context.CancelFunc
+}
+
+func newTypingTracker() *typingTracker {
+	return &typingTracker{
+		remote: map[typingKey]time.Time{},
+		local:  map[networkid.PortalKey]*localTyping{},
+	}
+}
+
+func (l *LinkedInClient) onRealtimeTypingIndicator(ctx context.Context, decoratedEvent *linkedingo.DecoratedEvent, typingIndicator *linkedingo.RealtimeTypingIndicator) {
+	if typingIndicator == nil {
+		return
+	}
+	meta := simplevent.EventMeta{
+		Type: bridgev2.RemoteEventTyping,
+		LogContext: func(c zerolog.Context) zerolog.Context {
+			return c.
+				Stringer("conversation_urn", typingIndicator.Conversation.EntityURN).
+				Stringer("typing_participant_urn", typingIndicator.TypingParticipant.EntityURN)
+		},
+		PortalKey: l.makePortalKey(typingIndicator.Conversation),
+		Sender:    l.makeSender(typingIndicator.TypingParticipant),
+		Timestamp: decoratedEvent.LeftServerAt.Time,
+	}
+
+	l.typing.lock.Lock()
+	l.typing.remote[typingKey{portal: meta.PortalKey, sender: meta.Sender.Sender}] = time.Now().Add(linkedInTypingTimeout)
+	l.typing.lock.Unlock()
+
+	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Typing{
+		EventMeta: meta,
+		Timeout:   linkedInTypingTimeout,
+		Type:      bridgev2.TypingTypeText,
+	})
+}
+
+// stopRemoteTyping clears the typing indicator of the sender of a message
+// instead of waiting for it to expire.
+func (l *LinkedInClient) stopRemoteTyping(portalKey networkid.PortalKey, sender bridgev2.EventSender) {
+	key := typingKey{portal: portalKey, sender: sender.Sender}
+	l.typing.lock.Lock()
+	expiry, ok := l.typing.remote[key]
+	delete(l.typing.remote, key)
+	l.typing.lock.Unlock()
+	if !ok || time.Now().After(expiry) {
+		return
+	}
+	l.main.Bridge.QueueRemoteEvent(l.userLogin, &simplevent.Typing{
+		EventMeta: simplevent.EventMeta{
+			Type: bridgev2.RemoteEventTyping,
+			LogContext: func(c zerolog.Context) zerolog.Context {
+				return c.Str("update", "typing_stop")
+			},
+			PortalKey: portalKey,
+			Sender:    sender,
+		},
+		Timeout: 0,
+		Type:    bridgev2.TypingTypeText,
+	})
+}
+
+// startLocalTyping sends the typing indicator to LinkedIn and keeps re-sending
+// it until stopLocalTyping is called.
+func (l *LinkedInClient) startLocalTyping(ctx context.Context, portal *bridgev2.Portal) error {
+	convURN := l.conversationURN(portal.ID)
+	log := zerolog.Ctx(ctx).With().Stringer("conversation_urn", convURN).Logger()
+	refreshCtx, cancel := context.WithTimeout(log.WithContext(context.Background()), maxTypingDuration)
+	entry := &localTyping{cancel: cancel}
+	l.typing.lock.Lock()
+	if _, ok := l.typing.local[portal.PortalKey]; ok {
+		l.typing.lock.Unlock()
+		cancel()
+		return nil
+	}
+	l.typing.local[portal.PortalKey] = entry
+	l.typing.lock.Unlock()
+
+	if err := l.client.StartTyping(refreshCtx, convURN); err != nil {
+		l.removeLocalTyping(portal.PortalKey, entry)
+		return err
+	}
+	go func() {
+		defer l.removeLocalTyping(portal.PortalKey, entry)
+		ticker := time.NewTicker(typingRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-refreshCtx.Done():
+				return
+			case <-ticker.C:
+				if err := l.client.StartTyping(refreshCtx, convURN); err != nil && refreshCtx.Err() == nil {
+					log.Err(err).Msg("Failed to refresh typing indicator")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (l *LinkedInClient) stopLocalTyping(portalKey networkid.PortalKey) {
+	l.typing.lock.Lock()
+	entry, ok := l.typing.local[portalKey]
+	delete(l.typing.local, portalKey)
+	l.typing.lock.Unlock()
+	if ok {
+		entry.cancel()
+	}
+}
+
+// removeLocalTyping stops the given refresh loop and removes it unless it has
+// already been replaced by a new one.
+func (l *LinkedInClient) removeLocalTyping(portalKey networkid.PortalKey, entry *localTyping) {
+	l.typing.lock.Lock()
+	if l.typing.local[portalKey] == entry {
+		delete(l.typing.local, portalKey)
+	}
+	l.typing.lock.Unlock()
+	entry.cancel()
+}
+
+// StopAll stops all typing refresh loops.
+func (tt *typingTracker) StopAll() {
+	tt.lock.Lock()
+	local := tt.local
+	tt.local = map[networkid.PortalKey]*localTyping{}
+	tt.lock.Unlock()
+	for _, entry := range local {
+		entry.cancel()
+	}
+}
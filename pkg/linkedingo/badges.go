@@ -0,0 +1,48 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkedingo
+
+type BadgingItem string
+
+const (
+	BadgingItemMessaging     BadgingItem = "MESSAGING"
+	BadgingItemNotifications BadgingItem = "NOTIFICATIONS"
+	BadgingItemMyNetwork     BadgingItem = "MY_NETWORK"
+)
+
+// RealtimeBadgingItemCountsEvent represents a
+// com.linkedin.voyager.dash.notifications.RealtimeBadgingItemCountsEvent
+// object.
+type RealtimeBadgingItemCountsEvent struct {
+	BadgingItemCounts []BadgingItemCount `json:"badgingItemCounts,omitempty"`
+}
+
+// BadgingItemCount represents a
+// com.linkedin.voyager.dash.notifications.BadgingItemCount object.
+type BadgingItemCount struct {
+	BadgingItem BadgingItem `json:"badgingItem,omitempty"`
+	Count       int         `json:"count"`
+}
+
+// Counts returns the badge counts keyed by badging item.
+func (e *RealtimeBadgingItemCountsEvent) Counts() map[BadgingItem]int {
+	counts := make(map[BadgingItem]int, len(e.BadgingItemCounts))
+	for _, item := range e.BadgingItemCounts {
+		counts[item.BadgingItem] = item.Count
+	}
+	return counts
+}
@@ -48,7 +48,9 @@ type Client struct {
 	realtimeCancelFn  context.CancelFunc
 	realtimeWaitGroup sync.WaitGroup
 
-	handlers Handlers
+	handlers          Handlers
+	topicHandlers     map[string]TopicHandler
+	topicHandlersLock sync.RWMutex
 
 	pageInstance   string
 	xLITrack       string
@@ -111,13 +113,29 @@ func (c *Client) IsLoggedIn() bool {
 }
 
 type Handlers struct {
-	Heartbeat              func(context.Context)
-	ClientConnection       func(context.Context, *ClientConnection)
-	TransientDisconnect    func(context.Context, error)
-	BadCredentials         func(context.Context, error)
-	UnknownError           func(context.Context, error)
-	DecoratedEvent         func(context.Context, *DecoratedEvent)
+	Heartbeat           func(context.Context)
+	ClientConnection    func(context.Context, *ClientConnection)
+	TransientDisconnect func(context.Context, error)
+	BadCredentials      func(context.Context, error)
+	UnknownError        func(context.Context, error)
+	// DecoratedEvent is called for every decorated event before it's
+	// dispatched to the topic handler. Returning false drops the event.
+	DecoratedEvent         func(context.Context, *DecoratedEvent) bool
 	ConversationsSyncToken func(context.Context, string)
+
+	// RealtimeConversation is called with nil data if the event didn't
+	// include the conversation, in which case conversations should be
+	// refetched.
+	RealtimeConversation       TopicCallback[Conversation]
+	RealtimeConversationDelete TopicCallback[Conversation]
+	RealtimeMessage            TopicCallback[Message]
+	RealtimeTypingIndicator    TopicCallback[RealtimeTypingIndicator]
+	RealtimeSeenReceipt        TopicCallback[SeenReceipt]
+	RealtimeReactionSummary    TopicCallback[RealtimeReactionSummary]
+	RealtimeBadgeCounts        TopicCallback[RealtimeBadgingItemCountsEvent]
+	RealtimeInAppAlert         TopicCallback[InAppAlert]
+	RealtimeInvitation         TopicCallback[RealtimeInvitationEvent]
+	RealtimeReplySuggestions   TopicCallback[RealtimeQuickReplyRecommendation]
 }
 
 func (h Handlers) onHeartbeat(ctx context.Context) {
@@ -150,10 +168,11 @@ func (h Handlers) onUnknownError(ctx context.Context, err error) {
 	}
 }
 
-func (h Handlers) onDecoratedEvent(ctx context.Context, decoratedEvent *DecoratedEvent) {
+func (h Handlers) onDecoratedEvent(ctx context.Context, decoratedEvent *DecoratedEvent) bool {
 	if h.DecoratedEvent != nil {
-		h.DecoratedEvent(ctx, decoratedEvent)
+		return h.DecoratedEvent(ctx, decoratedEvent)
 	}
+	return true
 }
 
 func (h Handlers) onConversationsSyncToken(ctx context.Context, conversationsSyncToken string) {
@@ -25,9 +25,14 @@ const (
 	linkedInRealtimeConnectURL                       = "https://www.linkedin.com/realtime/connect"
 	linkedInRealtimeHeartbeatURL                     = "https://www.linkedin.com/realtime/realtimeFrontendClientConnectivityTracking"
 	linkedInVoyagerCommonMeURL                       = "https://www.linkedin.com/voyager/api/me"
+	linkedInVoyagerIdentityDashNotificationCardsURL  = "https://www.linkedin.com/voyager/api/voyagerIdentityDashNotificationCards"
 	linkedInVoyagerMediaUploadMetadataURL            = "https://www.linkedin.com/voyager/api/voyagerVideoDashMediaUploadMetadata"
 	linkedInVoyagerMessagingDashMessengerMessagesURL = "https://www.linkedin.com/voyager/api/voyagerMessagingDashMessengerMessages"
 	linkedInVoyagerNotificationsDashPushRegistration = "https://www.linkedin.com/voyager/api/voyagerNotificationsDashPushRegistration"
+
+	linkedInVoyagerRelationshipsDashInvitationsURL         = "https://www.linkedin.com/voyager/api/voyagerRelationshipsDashInvitations"
+	linkedInVoyagerRelationshipsDashInvitationViewsURL     = "https://www.linkedin.com/voyager/api/voyagerRelationshipsDashInvitationViews"
+	linkedInVoyagerRelationshipsDashMemberRelationshipsURL = "https://www.linkedin.com/voyager/api/voyagerRelationshipsDashMemberRelationships"
 )
 
 const LinkedInCookieJSESSIONID = "JSESSIONID"
@@ -66,6 +66,20 @@ type Image struct {
 	Attributes []ImageAttribute `json:"attributes,omitempty"`
 }
 
+// GetLargestArtifactURL returns the URL of the largest artifact of the first
+// vector image attribute, or an empty string if there is none.
+func (i *Image) GetLargestArtifactURL() string {
+	if i == nil {
+		return ""
+	}
+	for _, attr := range i.Attributes {
+		if attr.DetailData != nil && attr.DetailData.VectorImage != nil {
+			return attr.DetailData.VectorImage.GetLargestArtifactURL()
+		}
+	}
+	return ""
+}
+
 type ImageAttribute struct {
 	DetailData *ImageDetailData `json:"detailData,omitempty"`
 }
@@ -87,6 +101,13 @@ type TextViewModel struct {
 	Text string `json:"text,omitempty"`
 }
 
+func (t *TextViewModel) GetText() string {
+	if t == nil {
+		return ""
+	}
+	return t.Text
+}
+
 // CollectionResponse represents a
 // com.linkedin.restli.common.CollectionResponse object.
 type CollectionResponse[M, T any] struct {
@@ -217,10 +238,6 @@ func (c *Client) DeleteConversation(ctx context.Context, conversationURN URN) er
 	return err
 }
 
-type DecoratedConversation struct {
-	Result Conversation `json:"result,omitempty"`
-}
-
 type RenameConversationBody struct {
 	Title string `json:"title"`
 }
@@ -264,6 +281,11 @@ func (c *Client) AddParticipants(ctx context.Context, conversationURN URN, parti
 	return c.manageParticipants(ctx, conversationURN, participants, "addParticipants")
 }
 
+// LeaveConversation removes the current user from a group conversation.
+func (c *Client) LeaveConversation(ctx context.Context, conversationURN URN) error {
+	return c.RemoveParticipants(ctx, conversationURN, []URN{c.userEntityURN})
+}
+
 func (c *Client) RemoveParticipants(ctx context.Context, conversationURN URN, participants []URN) error {
 	prefix := "urn:li:msg_messagingParticipant:urn:li:fsd_profile"
 	for i, p := range participants {
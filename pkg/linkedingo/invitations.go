@@ -0,0 +1,155 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkedingo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/jsontime"
+)
+
+const InvitationTypeConnection = "CONNECTION"
+
+// Invitation represents a
+// com.linkedin.voyager.dash.relationships.invitation.Invitation object.
+type Invitation struct {
+	EntityURN      URN                `json:"entityUrn,omitempty"`
+	SharedSecret   string             `json:"sharedSecret,omitempty"`
+	InvitationType string             `json:"invitationType,omitempty"`
+	Message        string             `json:"customMessage,omitempty"`
+	SentTime       jsontime.UnixMilli `json:"sentTime,omitempty"`
+	InviterURN     URN                `json:"genericInviterUrn,omitempty"`
+}
+
+// InsightViewModel represents a
+// com.linkedin.voyager.dash.relationships.InsightViewModel object.
+type InsightViewModel struct {
+	Text *TextViewModel `json:"text,omitempty"`
+}
+
+// InvitationView represents a
+// com.linkedin.voyager.dash.relationships.invitation.InvitationView object.
+type InvitationView struct {
+	Invitation Invitation `json:"invitation"`
+	// Title is the name of the inviter.
+	Title *TextViewModel `json:"title,omitempty"`
+	// Subtitle is the headline of the inviter.
+	Subtitle *TextViewModel `json:"subtitle,omitempty"`
+	// Insight is usually the number of mutual connections.
+	Insight *InsightViewModel `json:"insight,omitempty"`
+}
+
+func (iv *InvitationView) MutualConnections() string {
+	if iv.Insight == nil {
+		return ""
+	}
+	return iv.Insight.Text.GetText()
+}
+
+type InvitationViewsResponse struct {
+	Paging   Paging           `json:"paging"`
+	Elements []InvitationView `json:"elements,omitempty"`
+}
+
+// RealtimeInvitationEvent is the payload of an invitationsTopic event. It
+// only identifies the invitation, the details have to be fetched with
+// [Client.GetReceivedInvitations].
+type RealtimeInvitationEvent struct {
+	EntityURN URN `json:"entityUrn,omitempty"`
+}
+
+// GetReceivedInvitations fetches a page of pending connection requests,
+// newest first.
+func (c *Client) GetReceivedInvitations(ctx context.Context, start, count int) (*InvitationViewsResponse, error) {
+	zerolog.Ctx(ctx).Info().
+		Int("start", start).
+		Int("count", count).
+		Msg("Getting received invitations")
+	var response InvitationViewsResponse
+	_, err := c.newAuthedRequest(http.MethodGet, linkedInVoyagerRelationshipsDashInvitationViewsURL).
+		WithQueryParam("decorationId", "com.linkedin.voyager.dash.deco.relationships.invitation.ReceivedInvitationViews-16").
+		WithQueryParam("q", "receivedInvitation").
+		WithQueryParam("start", strconv.Itoa(start)).
+		WithQueryParam("count", strconv.Itoa(count)).
+		WithHeader("accept", contentTypeJSON).
+		WithCSRF().
+		WithXLIHeaders().
+		Do(ctx, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) AcceptInvitation(ctx context.Context, invitation Invitation) error {
+	return c.doInvitationAction(ctx, invitation, "accept")
+}
+
+func (c *Client) IgnoreInvitation(ctx context.Context, invitation Invitation) error {
+	return c.doInvitationAction(ctx, invitation, "ignore")
+}
+
+func (c *Client) doInvitationAction(ctx context.Context, invitation Invitation, action string) error {
+	invitationType := invitation.InvitationType
+	if invitationType == "" {
+		invitationType = InvitationTypeConnection
+	}
+	url, err := url.JoinPath(linkedInVoyagerRelationshipsDashInvitationsURL, invitation.EntityURN.URLEscaped())
+	if err != nil {
+		return err
+	}
+	_, err = c.newAuthedRequest(http.MethodPost, url).
+		WithQueryParam("action", action).
+		WithContentType(contentTypePlaintextUTF8).
+		WithHeader("accept", contentTypeJSON).
+		WithCSRF().
+		WithXLIHeaders().
+		WithJSONPayload(map[string]any{
+			"invitationType": invitationType,
+			"sharedSecret":   invitation.SharedSecret,
+		}).
+		Do(ctx, nil)
+	return err
+}
+
+// SendInvitation sends a connection request to the given profile. The note is
+// optional.
+func (c *Client) SendInvitation(ctx context.Context, profileURN URN, note string) error {
+	payload := map[string]any{
+		"invitee": map[string]any{
+			"inviteeUnion": map[string]any{
+				"memberProfile": profileURN.AsFsdProfile(),
+			},
+		},
+	}
+	if note != "" {
+		payload["customMessage"] = note
+	}
+	_, err := c.newAuthedRequest(http.MethodPost, linkedInVoyagerRelationshipsDashMemberRelationshipsURL).
+		WithQueryParam("action", "verifyQuotaAndCreateV2").
+		WithContentType(contentTypePlaintextUTF8).
+		WithHeader("accept", contentTypeJSON).
+		WithCSRF().
+		WithXLIHeaders().
+		WithJSONPayload(payload).
+		Do(ctx, nil)
+	return err
+}
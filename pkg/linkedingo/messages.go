@@ -109,10 +109,6 @@ type MessageSentResponse struct {
 	Data Message `json:"value,omitempty"`
 }
 
-type DecoratedMessage struct {
-	Result Message `json:"result,omitempty"`
-}
-
 type SendProgressiveStreams struct {
 	BitRate            int       `json:"bitRate"`
 	Height             int       `json:"height"`
@@ -138,6 +134,7 @@ type Message struct {
 	ConversationURN         URN                     `json:"conversationUrn,omitempty"`
 	RenderContent           []RenderContent         `json:"renderContent,omitempty"`
 	ReactionSummaries       []ReactionSummary       `json:"reactionSummaries,omitempty"`
+	OriginToken             string                  `json:"originToken,omitempty"`
 }
 
 func (m Message) MessageID() networkid.MessageID {
@@ -191,6 +188,8 @@ func (c *Client) SendMessage(ctx context.Context, conversationURN URN, body Send
 		},
 		MailboxURN: c.userEntityURN.WithPrefix("urn", "li", "fsd_profile"),
 		TrackingID: random.String(16),
+		// Retries reuse the transaction ID, so let LinkedIn drop duplicates.
+		DedupeByClientGeneratedToken: true,
 	}
 
 	var messageSentResponse MessageSentResponse
@@ -276,16 +275,28 @@ func (c *Client) RecallMessage(ctx context.Context, messageURN URN) error {
 }
 
 func (c *Client) GetMessagesBefore(ctx context.Context, conversationURN URN, before time.Time, count int) (*CollectionResponse[MessageMetadata, Message], error) {
+	return c.getMessagesByAnchorTimestamp(ctx, conversationURN, before, count, 0)
+}
+
+// GetMessagesAfter returns up to count messages delivered at or after the given
+// time, oldest first.
+func (c *Client) GetMessagesAfter(ctx context.Context, conversationURN URN, after time.Time, count int) (*CollectionResponse[MessageMetadata, Message], error) {
+	return c.getMessagesByAnchorTimestamp(ctx, conversationURN, after, 0, count)
+}
+
+func (c *Client) getMessagesByAnchorTimestamp(ctx context.Context, conversationURN URN, anchor time.Time, countBefore, countAfter int) (*CollectionResponse[MessageMetadata, Message], error) {
 	zerolog.Ctx(ctx).Info().
-		Time("before", before).
-		Msg("Getting conversations delivered before")
+		Time("anchor", anchor).
+		Int("count_before", countBefore).
+		Int("count_after", countAfter).
+		Msg("Getting messages delivered around anchor timestamp")
 	var response GraphQlResponse
 	_, err := c.newAuthedRequest(http.MethodGet, linkedInVoyagerMessagingGraphQLURL).
 		WithGraphQLQuery(graphQLQueryIDMessengerMessagesByAnchorTimestamp, map[string]string{
-			"deliveredAt":     strconv.Itoa(int(before.UnixMilli())),
+			"deliveredAt":     strconv.Itoa(int(anchor.UnixMilli())),
 			"conversationUrn": url.QueryEscape(conversationURN.WithPrefix("urn", "li", "msg_conversation").String()),
-			"countBefore":     strconv.Itoa(count),
-			"countAfter":      "0",
+			"countBefore":     strconv.Itoa(countBefore),
+			"countAfter":      strconv.Itoa(countAfter),
 		}).
 		Do(ctx, &response)
 	if err != nil {
@@ -22,10 +22,6 @@ import (
 	"net/url"
 )
 
-type DecoratedReactionSummary struct {
-	Result RealtimeReactionSummary `json:"result,omitempty"`
-}
-
 // RealtimeReactionSummary represents a
 // com.linkedin.messenger.RealtimeReactionSummary object.
 type RealtimeReactionSummary struct {
@@ -72,18 +72,42 @@ type DecoratedEvent struct {
 	Payload      DecoratedEventPayload `json:"payload,omitempty"`
 }
 
+// DecoratedEventPayload is the payload of a decorated event. The contents
+// depend on the topic, so it is kept as raw JSON and decoded by the topic
+// handler (see [TopicHandler]).
 type DecoratedEventPayload struct {
 	Data DecoratedEventData `json:"data,omitempty"`
+	Raw  json.RawMessage    `json:"-"`
 }
 
+func (p *DecoratedEventPayload) UnmarshalJSON(data []byte) error {
+	p.Raw = data
+	var payload struct {
+		Data DecoratedEventData `json:"data,omitempty"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	p.Data = payload.Data
+	return nil
+}
+
+// DecoratedEventData is the data field of a GraphQL-decorated payload.
 type DecoratedEventData struct {
-	Type                        string                    `json:"_type,omitempty"`
-	DecoratedConversation       *DecoratedConversation    `json:"doDecorateConversationMessengerRealtimeDecoration,omitempty"`
-	DecoratedConversationDelete *DecoratedConversation    `json:"doDecorateConversationDeleteMessengerRealtimeDecoration,omitempty"`
-	DecoratedMessage            *DecoratedMessage         `json:"doDecorateMessageMessengerRealtimeDecoration,omitempty"`
-	DecoratedTypingIndicator    *DecoratedTypingIndicator `json:"doDecorateTypingIndicatorMessengerRealtimeDecoration,omitempty"`
-	DecoratedSeenReceipt        *DecoratedSeenReceipt     `json:"doDecorateSeenReceiptMessengerRealtimeDecoration,omitempty"`
-	DecoratedReactionSummary    *DecoratedReactionSummary `json:"doDecorateRealtimeReactionSummaryMessengerRealtimeDecoration,omitempty"`
+	Type string          `json:"_type,omitempty"`
+	Raw  json.RawMessage `json:"-"`
+}
+
+func (d *DecoratedEventData) UnmarshalJSON(data []byte) error {
+	d.Raw = data
+	var typed struct {
+		Type string `json:"_type,omitempty"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	d.Type = typed.Type
+	return nil
 }
 
 func (c *Client) RealtimeConnect(ctx context.Context) error {
@@ -270,7 +294,7 @@ func (c *Client) realtimeConnectLoop(ctx context.Context) {
 					Stringer("topic", realtimeEvent.DecoratedEvent.Topic).
 					Str("payload_type", realtimeEvent.DecoratedEvent.Payload.Data.Type).
 					Msg("Received decorated event")
-				c.handlers.onDecoratedEvent(ctx, realtimeEvent.DecoratedEvent)
+				c.dispatchDecoratedEvent(ctx, realtimeEvent.DecoratedEvent)
 			}
 		}
 		realtimeResp.Body.Close()
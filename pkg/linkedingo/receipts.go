@@ -29,10 +29,6 @@ import (
 	"go.mau.fi/util/jsontime"
 )
 
-type DecoratedSeenReceipt struct {
-	Result SeenReceipt `json:"result,omitempty"`
-}
-
 // SeenReceipt represents a com.linkedin.messenger.SeenReceipt object.
 type SeenReceipt struct {
 	SeenAt            jsontime.UnixMilli   `json:"seenAt,omitempty"`
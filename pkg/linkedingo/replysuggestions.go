@@ -0,0 +1,42 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkedingo
+
+// RealtimeQuickReplyRecommendation represents a
+// com.linkedin.messenger.RealtimeQuickReplyRecommendation object.
+type RealtimeQuickReplyRecommendation struct {
+	Message      Message      `json:"message,omitempty"`
+	Conversation Conversation `json:"conversation,omitempty"`
+	QuickReplies []QuickReply `json:"quickReplies,omitempty"`
+}
+
+// QuickReply represents a com.linkedin.messenger.QuickReply object.
+type QuickReply struct {
+	Content    AttributedText `json:"content,omitempty"`
+	TrackingID string         `json:"trackingId,omitempty"`
+}
+
+// Texts returns the text of each of the non-empty quick replies.
+func (r *RealtimeQuickReplyRecommendation) Texts() []string {
+	texts := make([]string, 0, len(r.QuickReplies))
+	for _, reply := range r.QuickReplies {
+		if reply.Content.Text != "" {
+			texts = append(texts, reply.Content.Text)
+		}
+	}
+	return texts
+}
@@ -54,6 +54,16 @@ var (
 	ErrTokenInvalidated = errors.New("access token is no longer valid")
 )
 
+// UnexpectedStatusError is returned by [authedRequest.Do] when LinkedIn
+// responds with a non-2xx status code.
+type UnexpectedStatusError struct {
+	StatusCode int
+}
+
+func (e UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
 func (c *Client) checkHTTPRedirect(req *http.Request, via []*http.Request) error {
 	if req.Response == nil {
 		return nil
@@ -279,7 +289,7 @@ func (a *authedRequest) Do(ctx context.Context, out any) (*http.Response, error)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return resp, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		return resp, UnexpectedStatusError{StatusCode: resp.StatusCode}
 	}
 
 	if out == nil {
@@ -0,0 +1,179 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkedingo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/rs/zerolog"
+)
+
+// TopicHandler decodes the payload of a decorated event for a single realtime
+// topic and calls the corresponding typed callback in [Handlers].
+type TopicHandler func(ctx context.Context, h Handlers, evt *DecoratedEvent) error
+
+// TopicCallback is a typed callback for the data of a single realtime topic.
+type TopicCallback[T any] func(ctx context.Context, evt *DecoratedEvent, data *T)
+
+type messengerDecoration[T any] struct {
+	Result *T `json:"result,omitempty"`
+}
+
+// NewMessengerTopicHandler returns a [TopicHandler] for topics which are
+// decorated with a messenger GraphQL query. The payload data contains a single
+// object under decorationKey, whose result is decoded into T.
+//
+// If the payload doesn't contain the decoration, the callback is called with
+// nil data.
+func NewMessengerTopicHandler[T any](decorationKey string, getCallback func(Handlers) TopicCallback[T]) TopicHandler {
+	return func(ctx context.Context, h Handlers, evt *DecoratedEvent) error {
+		callback := getCallback(h)
+		if callback == nil {
+			return nil
+		}
+		var data map[string]json.RawMessage
+		if len(evt.Payload.Data.Raw) > 0 {
+			if err := json.Unmarshal(evt.Payload.Data.Raw, &data); err != nil {
+				return fmt.Errorf("failed to decode payload data: %w", err)
+			}
+		}
+		var decoration messengerDecoration[T]
+		if raw, ok := data[decorationKey]; ok {
+			if err := json.Unmarshal(raw, &decoration); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", decorationKey, err)
+			}
+		}
+		callback(ctx, evt, decoration.Result)
+		return nil
+	}
+}
+
+// NewRecipeTopicHandler returns a [TopicHandler] for topics which are
+// decorated with a Rest.li recipe (see x-li-recipe-map.json). The whole
+// payload is decoded into T.
+func NewRecipeTopicHandler[T any](getCallback func(Handlers) TopicCallback[T]) TopicHandler {
+	return func(ctx context.Context, h Handlers, evt *DecoratedEvent) error {
+		callback := getCallback(h)
+		if callback == nil {
+			return nil
+		}
+		var data T
+		if err := json.Unmarshal(evt.Payload.Raw, &data); err != nil {
+			return fmt.Errorf("failed to decode payload: %w", err)
+		}
+		callback(ctx, evt, &data)
+		return nil
+	}
+}
+
+// ignoreTopic is a [TopicHandler] for topics which are received but not
+// bridged.
+func ignoreTopic(ctx context.Context, h Handlers, evt *DecoratedEvent) error {
+	return nil
+}
+
+var defaultTopicHandlers = map[string]TopicHandler{
+	// Presence updates of connections aren't bridged, but they are frequent,
+	// so they shouldn't be logged as an unsupported topic.
+	RealtimeEventTopicPresenceStatus: ignoreTopic,
+	RealtimeEventTopicConversations: NewMessengerTopicHandler(
+		"doDecorateConversationMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[Conversation] { return h.RealtimeConversation },
+	),
+	RealtimeEventTopicTabBadgeUpdate: NewRecipeTopicHandler(
+		func(h Handlers) TopicCallback[RealtimeBadgingItemCountsEvent] { return h.RealtimeBadgeCounts },
+	),
+	RealtimeEventTopicInAppAlerts: NewRecipeTopicHandler(
+		func(h Handlers) TopicCallback[InAppAlert] { return h.RealtimeInAppAlert },
+	),
+	RealtimeEventTopicInvitations: NewRecipeTopicHandler(
+		func(h Handlers) TopicCallback[RealtimeInvitationEvent] { return h.RealtimeInvitation },
+	),
+	RealtimeEventTopicConversationDelete: NewMessengerTopicHandler(
+		"doDecorateConversationDeleteMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[Conversation] { return h.RealtimeConversationDelete },
+	),
+	RealtimeEventTopicMessages: NewMessengerTopicHandler(
+		"doDecorateMessageMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[Message] { return h.RealtimeMessage },
+	),
+	RealtimeEventTopicTypingIndicators: NewMessengerTopicHandler(
+		"doDecorateTypingIndicatorMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[RealtimeTypingIndicator] { return h.RealtimeTypingIndicator },
+	),
+	RealtimeEventTopicMessageSeenReceipts: NewMessengerTopicHandler(
+		"doDecorateSeenReceiptMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[SeenReceipt] { return h.RealtimeSeenReceipt },
+	),
+	RealtimeEventTopicMessageReactionSummaries: NewMessengerTopicHandler(
+		"doDecorateRealtimeReactionSummaryMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[RealtimeReactionSummary] { return h.RealtimeReactionSummary },
+	),
+	RealtimeEventTopicReplySuggestionV2: NewMessengerTopicHandler(
+		"doDecorateRealtimeQuickReplyRecommendationMessengerRealtimeDecoration",
+		func(h Handlers) TopicCallback[RealtimeQuickReplyRecommendation] { return h.RealtimeReplySuggestions },
+	),
+}
+
+// RegisterTopicHandler sets the handler for the given realtime topic name
+// (e.g. [RealtimeEventTopicMessages]), replacing any existing handler.
+func (c *Client) RegisterTopicHandler(topic string, handler TopicHandler) {
+	c.topicHandlersLock.Lock()
+	defer c.topicHandlersLock.Unlock()
+	if c.topicHandlers == nil {
+		c.topicHandlers = maps.Clone(defaultTopicHandlers)
+	}
+	c.topicHandlers[topic] = handler
+}
+
+func (c *Client) getTopicHandler(topic string) (TopicHandler, bool) {
+	c.topicHandlersLock.RLock()
+	defer c.topicHandlersLock.RUnlock()
+	if c.topicHandlers == nil {
+		handler, ok := defaultTopicHandlers[topic]
+		return handler, ok
+	}
+	handler, ok := c.topicHandlers[topic]
+	return handler, ok
+}
+
+func (c *Client) dispatchDecoratedEvent(ctx context.Context, evt *DecoratedEvent) {
+	if !c.handlers.onDecoratedEvent(ctx, evt) {
+		return
+	}
+	log := zerolog.Ctx(ctx).With().
+		Str("decorated_event_id", evt.ID).
+		Stringer("topic", evt.Topic).
+		Logger()
+	ctx = log.WithContext(ctx)
+	// The topics are always of the form "urn:li-realtime:TOPIC_NAME:<topic_dependent>"
+	topic := evt.Topic.NthPrefixPart(2)
+	handler, ok := c.getTopicHandler(topic)
+	if !ok {
+		log.Warn().Msg("Unsupported event topic")
+		log.Trace().RawJSON("payload", evt.Payload.Raw).Msg("Unsupported event topic payload")
+		return
+	}
+	if err := handler(ctx, c.handlers, evt); err != nil {
+		log.Err(err).
+			RawJSON("payload", evt.Payload.Raw).
+			Msg("Failed to handle decorated event")
+	}
+}
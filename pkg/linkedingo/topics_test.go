@@ -0,0 +1,89 @@
+// mautrix-linkedin - A Matrix-LinkedIn puppeting bridge.
+// Copyright (C) 2025 Sumner Evans
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkedingo_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-linkedin/pkg/linkedingo"
+)
+
+const messageEventJSON = `{
+	"topic": "urn:li-realtime:messagesTopic:urn:li-realtime:myself",
+	"leftServerAt": 1700000000000,
+	"id": "event-1",
+	"payload": {
+		"data": {
+			"_type": "com.linkedin.messenger.RealtimeDecoration",
+			"doDecorateMessageMessengerRealtimeDecoration": {
+				"result": {
+					"entityUrn": "urn:li:msg_message:(urn:li:fsd_profile:ACoAAA,2-abc)",
+					"body": {"text": "hello"},
+					"deliveredAt": 1700000000000
+				}
+			}
+		}
+	}
+}`
+
+func TestMessengerTopicHandler(t *testing.T) {
+	var evt linkedingo.DecoratedEvent
+	require.NoError(t, json.Unmarshal([]byte(messageEventJSON), &evt))
+	assert.Equal(t, "com.linkedin.messenger.RealtimeDecoration", evt.Payload.Data.Type)
+	assert.Equal(t, linkedingo.RealtimeEventTopicMessages, evt.Topic.NthPrefixPart(2))
+
+	var received *linkedingo.Message
+	handler := linkedingo.NewMessengerTopicHandler(
+		"doDecorateMessageMessengerRealtimeDecoration",
+		func(h linkedingo.Handlers) linkedingo.TopicCallback[linkedingo.Message] { return h.RealtimeMessage },
+	)
+	err := handler(context.TODO(), linkedingo.Handlers{
+		RealtimeMessage: func(ctx context.Context, evt *linkedingo.DecoratedEvent, msg *linkedingo.Message) {
+			received = msg
+		},
+	}, &evt)
+	require.NoError(t, err)
+	require.NotNil(t, received)
+	assert.Equal(t, "hello", received.Body.Text)
+	assert.Equal(t, "urn:li:msg_message:(urn:li:fsd_profile:ACoAAA,2-abc)", received.EntityURN.String())
+}
+
+func TestMessengerTopicHandlerMissingDecoration(t *testing.T) {
+	var evt linkedingo.DecoratedEvent
+	require.NoError(t, json.Unmarshal([]byte(`{"topic":"urn:li-realtime:conversationsTopic:urn:li-realtime:myself","payload":{"data":{}}}`), &evt))
+
+	called := false
+	handler := linkedingo.NewMessengerTopicHandler(
+		"doDecorateConversationMessengerRealtimeDecoration",
+		func(h linkedingo.Handlers) linkedingo.TopicCallback[linkedingo.Conversation] {
+			return h.RealtimeConversation
+		},
+	)
+	err := handler(context.TODO(), linkedingo.Handlers{
+		RealtimeConversation: func(ctx context.Context, evt *linkedingo.DecoratedEvent, conv *linkedingo.Conversation) {
+			called = true
+			assert.Nil(t, conv)
+		},
+	}, &evt)
+	require.NoError(t, err)
+	assert.True(t, called)
+}
@@ -21,10 +21,6 @@ import (
 	"net/http"
 )
 
-type DecoratedTypingIndicator struct {
-	Result RealtimeTypingIndicator `json:"result,omitempty"`
-}
-
 // RealtimeTypingIndicator represents a
 // com.linkedin.messenger.RealtimeTypingIndicator object.
 type RealtimeTypingIndicator struct {
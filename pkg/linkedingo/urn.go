@@ -97,6 +97,13 @@ func (u URN) WithPrefix(prefix ...string) (n URN) {
 	return
 }
 
+// WithID returns a URN with the same prefix but the given ID
+func (u URN) WithID(id string) (n URN) {
+	n.prefix = u.prefix
+	n.id = id
+	return
+}
+
 func (u URN) AsFsdProfile() URN {
 	return u.WithPrefix("urn", "li", "fsd_profile")
 }